@@ -0,0 +1,292 @@
+package milo
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	envPrefix = "MILO_"
+)
+
+// A time.Duration which is read from config files as a string like "10s" or "1m30s".
+type Duration time.Duration
+
+// Parse the duration from its string form.
+func (d *Duration) UnmarshalText(text []byte) error {
+	parsed, err := time.ParseDuration(string(text))
+	if err != nil {
+		return err
+	}
+	*d = Duration(parsed)
+	return nil
+}
+
+// Stringer implementation.
+func (d Duration) String() string {
+	return time.Duration(d).String()
+}
+
+// Write the duration in its string form.
+func (d Duration) MarshalText() ([]byte, error) {
+	return []byte(time.Duration(d).String()), nil
+}
+
+// Milo configuration loaded from json, toml or yaml files and MILO_* environment variables.  Implements Configer,
+// so it can be passed to the renderer and templates get the config automatically.  Keys which aren't milo settings
+// are kept in Values and read with GetConfig.
+type AppConfig struct {
+	Bind              string                 `json:"bind" toml:"bind" yaml:"bind"`
+	Port              int                    `json:"port" toml:"port" yaml:"port"`
+	PortIncrement     bool                   `json:"port_increment" toml:"port_increment" yaml:"port_increment"`
+	UnixSocket        string                 `json:"unix_socket" toml:"unix_socket" yaml:"unix_socket"`
+	SocketActivation  bool                   `json:"socket_activation" toml:"socket_activation" yaml:"socket_activation"`
+	GracefulRestart   bool                   `json:"graceful_restart" toml:"graceful_restart" yaml:"graceful_restart"`
+	CertFile          string                 `json:"cert_file" toml:"cert_file" yaml:"cert_file"`
+	KeyFile           string                 `json:"key_file" toml:"key_file" yaml:"key_file"`
+	CertReload        bool                   `json:"cert_reload" toml:"cert_reload" yaml:"cert_reload"`
+	DevTLS            bool                   `json:"dev_tls" toml:"dev_tls" yaml:"dev_tls"`
+	RedirectPort      int                    `json:"redirect_port" toml:"redirect_port" yaml:"redirect_port"`
+	DrainTimeout      Duration               `json:"drain_timeout" toml:"drain_timeout" yaml:"drain_timeout"`
+	ShutdownDelay     Duration               `json:"shutdown_delay" toml:"shutdown_delay" yaml:"shutdown_delay"`
+	ReadTimeout       Duration               `json:"read_timeout" toml:"read_timeout" yaml:"read_timeout"`
+	ReadHeaderTimeout Duration               `json:"read_header_timeout" toml:"read_header_timeout" yaml:"read_header_timeout"`
+	WriteTimeout      Duration               `json:"write_timeout" toml:"write_timeout" yaml:"write_timeout"`
+	IdleTimeout       Duration               `json:"idle_timeout" toml:"idle_timeout" yaml:"idle_timeout"`
+	MaxHeaderBytes    int                    `json:"max_header_bytes" toml:"max_header_bytes" yaml:"max_header_bytes"`
+	TemplateDir       string                 `json:"template_dir" toml:"template_dir" yaml:"template_dir"`
+	CacheTemplates    bool                   `json:"cache_templates" toml:"cache_templates" yaml:"cache_templates"`
+	Values            map[string]interface{} `json:"values" toml:"values" yaml:"values"`
+}
+
+// Create a config with the milo defaults.
+func NewAppConfig() *AppConfig {
+	return &AppConfig{
+		Port:         7000,
+		DrainTimeout: Duration(15 * time.Second),
+		ReadTimeout:  Duration(10 * time.Second),
+		WriteTimeout: Duration(10 * time.Second),
+		Values:       make(map[string]interface{}),
+	}
+}
+
+// Load config from the files in order, later files override earlier ones, then apply MILO_* environment overrides
+// and validate.  The format is picked from the file extension: .json, .toml, .yaml or .yml.
+func LoadConfig(paths ...string) (*AppConfig, error) {
+	c := NewAppConfig()
+	for _, path := range paths {
+		if err := c.LoadFile(path); err != nil {
+			return nil, err
+		}
+	}
+	if err := c.LoadEnv(os.Environ()); err != nil {
+		return nil, err
+	}
+	if err := c.Validate(); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// Load a config file over the current values.
+func (c *AppConfig) LoadFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		err = json.Unmarshal(data, c)
+	case ".toml":
+		err = toml.Unmarshal(data, c)
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, c)
+	default:
+		return fmt.Errorf("milo: unknown config format: %s", path)
+	}
+	if err != nil {
+		return fmt.Errorf("milo: config %s: %w", path, err)
+	}
+	return nil
+}
+
+// Apply MILO_* overrides from the environment list, in the KEY=value form of os.Environ.  MILO_PORT sets port and
+// so on for each setting, any other MILO_* variable is stored in Values under its lowercased name.
+func (c *AppConfig) LoadEnv(environ []string) error {
+	fields := c.settingFields()
+	var errs []error
+	for _, kv := range environ {
+		key, value, ok := strings.Cut(kv, "=")
+		if !ok || !strings.HasPrefix(key, envPrefix) || key == envListenFds || key == envListenFdNames {
+			continue
+		}
+		name := strings.ToLower(strings.TrimPrefix(key, envPrefix))
+		field, ok := fields[name]
+		if !ok {
+			if c.Values == nil {
+				c.Values = make(map[string]interface{})
+			}
+			c.Values[name] = value
+			continue
+		}
+		if err := setField(field, value); err != nil {
+			errs = append(errs, fmt.Errorf("milo: %s: %w", key, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Check the settings are usable, all problems are reported together.
+func (c *AppConfig) Validate() error {
+	var errs []error
+	if c.Port < 0 || c.Port > maxPort {
+		errs = append(errs, fmt.Errorf("milo: port %d out of range", c.Port))
+	}
+	if c.RedirectPort < 0 || c.RedirectPort > maxPort {
+		errs = append(errs, fmt.Errorf("milo: redirect port %d out of range", c.RedirectPort))
+	}
+	if (c.CertFile == "") != (c.KeyFile == "") {
+		errs = append(errs, errors.New("milo: cert_file and key_file must be set together"))
+	}
+	if c.CertReload && c.CertFile == "" {
+		errs = append(errs, errors.New("milo: cert_reload requires cert_file"))
+	}
+	if c.RedirectPort > 0 && c.CertFile == "" && !c.DevTLS {
+		errs = append(errs, errors.New("milo: redirect_port requires tls"))
+	}
+	if c.MaxHeaderBytes < 0 {
+		errs = append(errs, errors.New("milo: max_header_bytes can't be negative"))
+	}
+	for name, d := range map[string]Duration{"drain_timeout": c.DrainTimeout, "shutdown_delay": c.ShutdownDelay, "read_timeout": c.ReadTimeout,
+		"read_header_timeout": c.ReadHeaderTimeout, "write_timeout": c.WriteTimeout, "idle_timeout": c.IdleTimeout} {
+		if d < 0 {
+			errs = append(errs, fmt.Errorf("milo: %s can't be negative", name))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Get a config value by key.  Values are looked up first, nested maps can be reached with dotted keys like
+// "database.url", then the milo settings by their file names like "port".
+func (c *AppConfig) GetConfig(key string) interface{} {
+	if v, ok := lookupValue(c.Values, key); ok {
+		return v
+	}
+	if field, ok := c.settingFields()[key]; ok {
+		return field.Interface()
+	}
+	return nil
+}
+
+// The configuration options for building a milo app from the config.
+func (c *AppConfig) Options() []func(*Milo) error {
+	opts := []func(*Milo) error{
+		SetBind(c.Bind),
+		SetPort(c.Port),
+		SetPortInc(c.PortIncrement),
+		SetSocketActivation(c.SocketActivation),
+		SetGracefulRestart(c.GracefulRestart),
+		SetDevTLS(c.DevTLS),
+		SetDrainTimeout(time.Duration(c.DrainTimeout)),
+		SetShutdownDelay(time.Duration(c.ShutdownDelay)),
+		SetReadTimeout(time.Duration(c.ReadTimeout)),
+		SetReadHeaderTimeout(time.Duration(c.ReadHeaderTimeout)),
+		SetWriteTimeout(time.Duration(c.WriteTimeout)),
+		SetIdleTimeout(time.Duration(c.IdleTimeout)),
+		SetMaxHeaderBytes(c.MaxHeaderBytes),
+		SetTLSReload(c.CertReload),
+	}
+	if c.UnixSocket != "" {
+		opts = append(opts, SetUnixSocket(c.UnixSocket))
+	}
+	if c.CertFile != "" {
+		opts = append(opts, SetTLS(c.CertFile, c.KeyFile))
+	}
+	if c.RedirectPort > 0 {
+		opts = append(opts, SetTLSRedirect(c.RedirectPort))
+	}
+	return opts
+}
+
+// Build a milo app and renderer from the config, the renderer is registered with the app and gets the config
+// for its templates.  Extra options are applied after the config options.
+func (c *AppConfig) NewApp(opts ...func(*Milo) error) (*Milo, *Renderer, error) {
+	if err := c.Validate(); err != nil {
+		return nil, nil, err
+	}
+	app, err := NewMilo(append(c.Options(), opts...)...)
+	if err != nil {
+		return nil, nil, err
+	}
+	rend := NewRenderer(c.TemplateDir, c.CacheTemplates, c)
+	app.RegisterRenderer(rend)
+	return app, rend, nil
+}
+
+// The settable fields keyed by their file names.
+func (c *AppConfig) settingFields() map[string]reflect.Value {
+	fields := make(map[string]reflect.Value)
+	v := reflect.ValueOf(c).Elem()
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		name := t.Field(i).Tag.Get("json")
+		if name == "" || name == "values" {
+			continue
+		}
+		fields[name] = v.Field(i)
+	}
+	return fields
+}
+
+// Set a setting field from its string form.
+func setField(field reflect.Value, value string) error {
+	if field.Type() == reflect.TypeOf(Duration(0)) {
+		return field.Addr().Interface().(*Duration).UnmarshalText([]byte(value))
+	}
+	switch field.Kind() {
+	case reflect.String:
+		field.SetString(value)
+	case reflect.Int:
+		i, err := strconv.Atoi(value)
+		if err != nil {
+			return err
+		}
+		field.SetInt(int64(i))
+	case reflect.Bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return err
+		}
+		field.SetBool(b)
+	default:
+		return fmt.Errorf("unsupported setting type %s", field.Type())
+	}
+	return nil
+}
+
+// Look up a dotted key in nested maps.
+func lookupValue(values map[string]interface{}, key string) (interface{}, bool) {
+	if v, ok := values[key]; ok {
+		return v, true
+	}
+	head, rest, ok := strings.Cut(key, ".")
+	if !ok {
+		return nil, false
+	}
+	switch nested := values[head].(type) {
+	case map[string]interface{}:
+		return lookupValue(nested, rest)
+	}
+	return nil, false
+}
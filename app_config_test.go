@@ -0,0 +1,131 @@
+package milo_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kendellfab/milo"
+)
+
+func TestLoadConfigFormats(t *testing.T) {
+	tests := []struct {
+		file string
+		data string
+	}{
+		{"app.json", `{"bind": "127.0.0.1", "port": 8080, "drain_timeout": "30s", "values": {"database": {"url": "postgres://db"}}}`},
+		{"app.toml", "bind = \"127.0.0.1\"\nport = 8080\ndrain_timeout = \"30s\"\n[values.database]\nurl = \"postgres://db\"\n"},
+		{"app.yaml", "bind: 127.0.0.1\nport: 8080\ndrain_timeout: 30s\nvalues:\n  database:\n    url: postgres://db\n"},
+		{"app.yml", "bind: 127.0.0.1\nport: 8080\ndrain_timeout: 30s\nvalues:\n  database:\n    url: postgres://db\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.file, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), tt.file)
+			if err := os.WriteFile(path, []byte(tt.data), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			c := milo.NewAppConfig()
+			if err := c.LoadFile(path); err != nil {
+				t.Fatal(err)
+			}
+			if c.Bind != "127.0.0.1" || c.Port != 8080 {
+				t.Errorf("expected 127.0.0.1:8080, got %s:%d", c.Bind, c.Port)
+			}
+			if time.Duration(c.DrainTimeout) != 30*time.Second {
+				t.Errorf("expected a 30s drain timeout, got %s", c.DrainTimeout)
+			}
+			if time.Duration(c.WriteTimeout) != 10*time.Second {
+				t.Errorf("expected the default write timeout to be kept, got %s", c.WriteTimeout)
+			}
+			if got := c.GetConfig("database.url"); got != "postgres://db" {
+				t.Errorf("expected database.url of postgres://db, got %v", got)
+			}
+			if got := c.GetConfig("port"); got != 8080 {
+				t.Errorf("expected port setting of 8080, got %v", got)
+			}
+		})
+	}
+}
+
+func TestLoadConfigUnknownFormat(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.ini")
+	if err := os.WriteFile(path, []byte("port=1"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := milo.NewAppConfig().LoadFile(path); err == nil || !strings.Contains(err.Error(), "unknown config format") {
+		t.Errorf("expected an unknown format error, got %v", err)
+	}
+}
+
+func TestLoadEnv(t *testing.T) {
+	tests := []struct {
+		name    string
+		env     []string
+		wantErr []string
+	}{
+		{name: "valid", env: []string{"MILO_PORT=9000", "MILO_PORT_INCREMENT=true", "MILO_READ_TIMEOUT=1m", "MILO_API_KEY=secret", "PATH=/bin"}},
+		{name: "bad int", env: []string{"MILO_PORT=nine"}, wantErr: []string{"MILO_PORT"}},
+		{name: "bad bool", env: []string{"MILO_PORT_INCREMENT=maybe"}, wantErr: []string{"MILO_PORT_INCREMENT"}},
+		{name: "bad duration", env: []string{"MILO_READ_TIMEOUT=soon"}, wantErr: []string{"MILO_READ_TIMEOUT"}},
+		{name: "errors joined", env: []string{"MILO_PORT=nine", "MILO_READ_TIMEOUT=soon"}, wantErr: []string{"MILO_PORT", "MILO_READ_TIMEOUT"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := milo.NewAppConfig()
+			err := c.LoadEnv(tt.env)
+			if len(tt.wantErr) == 0 {
+				if err != nil {
+					t.Fatal(err)
+				}
+				if c.Port != 9000 || !c.PortIncrement || time.Duration(c.ReadTimeout) != time.Minute {
+					t.Errorf("expected env overrides to be applied, got %+v", c)
+				}
+				if got := c.GetConfig("api_key"); got != "secret" {
+					t.Errorf("expected api_key of secret, got %v", got)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatal("expected an error")
+			}
+			for _, want := range tt.wantErr {
+				if !strings.Contains(err.Error(), want) {
+					t.Errorf("expected error to mention %s, got %v", want, err)
+				}
+			}
+		})
+	}
+}
+
+func TestAppConfigValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  func(c *milo.AppConfig)
+		wantErr string
+	}{
+		{name: "defaults", config: func(c *milo.AppConfig) {}},
+		{name: "port", config: func(c *milo.AppConfig) { c.Port = 70000 }, wantErr: "port 70000 out of range"},
+		{name: "cert without key", config: func(c *milo.AppConfig) { c.CertFile = "cert.pem" }, wantErr: "cert_file and key_file"},
+		{name: "reload without cert", config: func(c *milo.AppConfig) { c.CertReload = true }, wantErr: "cert_reload requires cert_file"},
+		{name: "redirect without tls", config: func(c *milo.AppConfig) { c.RedirectPort = 80 }, wantErr: "redirect_port requires tls"},
+		{name: "negative duration", config: func(c *milo.AppConfig) { c.IdleTimeout = -1 }, wantErr: "idle_timeout can't be negative"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := milo.NewAppConfig()
+			tt.config(c)
+			err := c.Validate()
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
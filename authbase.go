@@ -180,6 +180,16 @@ func (ab *AuthBase) DoLogin(w http.ResponseWriter, r *http.Request, id string) e
 	return nil
 }
 
+// Get the logged in id from the auth session without running the auth checks.
+func (ab *AuthBase) SessionID(r *http.Request) (string, bool) {
+	sess, sessErr := ab.store.Get(r, ab.authKey)
+	if sessErr != nil {
+		return "", false
+	}
+	id, ok := sess.Values[sessID].(string)
+	return id, ok
+}
+
 func (ab *AuthBase) DoLogout(w http.ResponseWriter, r *http.Request) error {
 	sess, sessErr := ab.store.Get(r, ab.authKey)
 	if sessErr != nil {
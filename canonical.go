@@ -0,0 +1,132 @@
+package milo
+
+import (
+	"net/http"
+	"path"
+	"strings"
+
+	"github.com/gorilla/mux"
+)
+
+// How requests for non canonical paths are handled.
+type PathPolicy int
+
+const (
+	// Paths must match a route exactly, gorilla's path cleaning is turned off so "//about" and "/a/../about" are 404s.
+	PathStrict PathPolicy = iota + 1
+	// Redirect to the canonical path, 301 for GET and HEAD and 308 otherwise so the method and body are kept.  Dot
+	// segments are always resolved.
+	PathRedirect
+	// Serve the canonical path in place.
+	PathLenient
+)
+
+// The differences between a requested path and a route which are canonicalized.
+type PathRule int
+
+const (
+	PathTrailingSlash    PathRule = 1 << iota // "/about/" and "/about" are the same route.
+	PathDuplicateSlashes                      // "/blog//posts" is "/blog/posts".
+	PathCase                                  // "/About" is "/about".
+)
+
+// Find the canonical request when the path doesn't match a route but a canonical form of it does, false when the
+// request should be routed as is.  Paths which match a route are never changed, so a route registered with a
+// trailing slash or capitals keeps working, and a catch all prefix route turns canonicalization off below it.
+func (m *Milo) canonicalRequest(r *http.Request) (*http.Request, bool) {
+	if m.pathPolicy != PathRedirect && m.pathPolicy != PathLenient {
+		return r, false
+	}
+	if m.matchesRoute(r) {
+		return r, false
+	}
+
+	canonical := r.URL.Path
+	if hasDotSegments(canonical) {
+		// Gorilla's path cleaning is off, so resolve "/a/../b" here whatever the rules.
+		cleaned := path.Clean(canonical)
+		if strings.HasSuffix(canonical, "/") && cleaned != "/" {
+			cleaned += "/"
+		}
+		canonical = cleaned
+	}
+	if m.pathRules&PathDuplicateSlashes != 0 {
+		canonical = collapseSlashes(canonical)
+	}
+	if m.pathRules&PathCase != 0 {
+		canonical = strings.ToLower(canonical)
+	}
+	candidates := []string{canonical}
+	if m.pathRules&PathTrailingSlash != 0 {
+		if canonical != "/" && strings.HasSuffix(canonical, "/") {
+			candidates = append(candidates, strings.TrimRight(canonical, "/"))
+		} else {
+			candidates = append(candidates, canonical+"/")
+		}
+	}
+
+	for _, candidate := range candidates {
+		if candidate == r.URL.Path || candidate == "" {
+			continue
+		}
+		u := *r.URL
+		u.Path, u.RawPath = candidate, ""
+		cr := r.Clone(r.Context())
+		cr.URL = &u
+		if m.matchesRoute(cr) {
+			return cr, true
+		}
+	}
+	return r, false
+}
+
+// Check the request matches a route, a method mismatch still counts.
+func (m *Milo) matchesRoute(r *http.Request) bool {
+	var match mux.RouteMatch
+	return m.router.Match(r, &match) && match.MatchErr != mux.ErrNotFound
+}
+
+// Apply the path policy, redirecting or rewriting requests for non canonical paths.  Returns true once a redirect
+// has been written.
+func (m *Milo) canonicalizePath(w http.ResponseWriter, r *http.Request) (*http.Request, bool) {
+	cr, ok := m.canonicalRequest(r)
+	if !ok {
+		return r, false
+	}
+	if m.pathPolicy == PathLenient {
+		cr.RequestURI = cr.URL.RequestURI()
+		return cr, false
+	}
+
+	code := http.StatusPermanentRedirect
+	if r.Method == http.MethodGet || r.Method == http.MethodHead {
+		code = http.StatusMovedPermanently
+	}
+	// A leading double slash would be a protocol relative url to another host.
+	location := "/" + strings.TrimLeft(cr.URL.RequestURI(), "/")
+	m.logger.LogInterfaces("Canonical:", r.URL.Path, location, code)
+	http.Redirect(w, r, location, code)
+	return r, true
+}
+
+// Check the path has "." or ".." segments.
+func hasDotSegments(p string) bool {
+	for _, segment := range strings.Split(p, "/") {
+		if segment == "." || segment == ".." {
+			return true
+		}
+	}
+	return false
+}
+
+// Collapse runs of slashes into one.
+func collapseSlashes(p string) string {
+	var out strings.Builder
+	for i := 0; i < len(p); i++ {
+		if p[i] == '/' && i > 0 && p[i-1] == '/' {
+			continue
+		}
+		out.WriteByte(p[i])
+	}
+	return out.String()
+}
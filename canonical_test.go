@@ -0,0 +1,45 @@
+package milo_test
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/kendellfab/milo"
+	"github.com/kendellfab/milo/milotest"
+)
+
+func newCanonicalApp(t *testing.T, opts ...func(*milo.Milo) error) *milotest.Client {
+	t.Helper()
+	app, err := milo.NewMilo(opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	app.Route("/about", []string{http.MethodGet, http.MethodPost}, okHandler)
+	app.Route("/admin/", []string{http.MethodGet}, okHandler)
+	app.Route("/users/{name}", []string{http.MethodGet}, okHandler)
+	return milotest.NewClient(t, app.Handler())
+}
+
+func TestPathRedirect(t *testing.T) {
+	c := newCanonicalApp(t, milo.SetPathPolicy(milo.PathRedirect))
+
+	c.Get("/about/?a=1&b=2").AssertStatus(http.StatusMovedPermanently).AssertRedirect("/about?a=1&b=2")
+	c.Send(milotest.NewRequest(http.MethodPost, "/about/")).AssertStatus(http.StatusPermanentRedirect).AssertRedirect("/about")
+	c.Get("//about").AssertStatus(http.StatusMovedPermanently).AssertRedirect("/about")
+	c.Get("/ABOUT").AssertStatus(http.StatusMovedPermanently).AssertRedirect("/about")
+	c.Get("/admin").AssertStatus(http.StatusMovedPermanently).AssertRedirect("/admin/")
+	c.Get("/x/../about").AssertStatus(http.StatusMovedPermanently).AssertRedirect("/about")
+	c.Get("/users/Bob").AssertStatus(http.StatusOK)
+	c.Get("//evil.com/").AssertStatus(http.StatusNotFound)
+}
+
+func TestPathLenientAndStrict(t *testing.T) {
+	lenient := newCanonicalApp(t, milo.SetPathPolicy(milo.PathLenient, milo.PathTrailingSlash))
+	lenient.Get("/about/").AssertStatus(http.StatusOK)
+	lenient.Get("/ABOUT").AssertStatus(http.StatusNotFound)
+
+	strict := newCanonicalApp(t, milo.SetPathPolicy(milo.PathStrict))
+	strict.Get("/about/").AssertStatus(http.StatusNotFound)
+	strict.Get("//about").AssertStatus(http.StatusNotFound)
+	strict.Get("/about").AssertStatus(http.StatusOK)
+}
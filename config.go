@@ -1,8 +1,23 @@
 package milo
 
-// Configuration option to change the bind address.
+import (
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+)
+
+// Configuration option to change the bind address.  A bracketed ipv6 address like "[::1]" is stored without the
+// brackets, they are added back when the port is joined on.
 func SetBind(bind string) func(*Milo) error {
 	return func(m *Milo) error {
+		if strings.HasPrefix(bind, "[") && strings.HasSuffix(bind, "]") && net.ParseIP(bind[1:len(bind)-1]) != nil {
+			bind = bind[1 : len(bind)-1]
+		}
+		if !validBind(bind) {
+			return fmt.Errorf("milo: invalid bind address %q", bind)
+		}
 		m.bind = bind
 		return nil
 	}
@@ -11,6 +26,9 @@ func SetBind(bind string) func(*Milo) error {
 // Configuration option to change the bind port.
 func SetPort(port int) func(*Milo) error {
 	return func(m *Milo) error {
+		if port < 0 || port > maxPort {
+			return fmt.Errorf("milo: port %d out of range", port)
+		}
 		m.port = port
 		return nil
 	}
@@ -23,3 +41,237 @@ func SetPortInc(inc bool) func(*Milo) error {
 		return nil
 	}
 }
+
+// Configuration option to serve on a unix domain socket at the path instead of the bind address and port.
+func SetUnixSocket(path string) func(*Milo) error {
+	return func(m *Milo) error {
+		if path == "" {
+			return errors.New("milo: unix socket path required")
+		}
+		m.unixSocket = path
+		return nil
+	}
+}
+
+// Configuration option to use sockets passed in through systemd socket activation (LISTEN_FDS) when present.
+func SetSocketActivation(activation bool) func(*Milo) error {
+	return func(m *Milo) error {
+		m.socketActivation = activation
+		return nil
+	}
+}
+
+// Configuration option to restart on SIGHUP or SIGUSR2 by starting a new process of the binary with the listening
+// sockets handed over, the old process then drains with a graceful shutdown.
+func SetGracefulRestart(restart bool) func(*Milo) error {
+	return func(m *Milo) error {
+		m.gracefulRestart = restart
+		return nil
+	}
+}
+
+// Configuration option to serve over https with the given certificate and key files.
+func SetTLS(certFile, keyFile string) func(*Milo) error {
+	return func(m *Milo) error {
+		if certFile == "" || keyFile == "" {
+			return errors.New("milo: tls requires both a certificate and key file")
+		}
+		m.certFile = certFile
+		m.keyFile = keyFile
+		return nil
+	}
+}
+
+// Configuration option to reload the tls certificate when the files change on disk.
+func SetTLSReload(reload bool) func(*Milo) error {
+	return func(m *Milo) error {
+		m.certReload = reload
+		return nil
+	}
+}
+
+// Configuration option to start a plain http listener on the given port that redirects to https.
+func SetTLSRedirect(port int) func(*Milo) error {
+	return func(m *Milo) error {
+		if port < 1 || port > maxPort {
+			return fmt.Errorf("milo: redirect port %d out of range", port)
+		}
+		m.redirectPort = port
+		return nil
+	}
+}
+
+// Configuration option to serve https with a self signed certificate generated on startup.  Development use only.
+func SetDevTLS(dev bool) func(*Milo) error {
+	return func(m *Milo) error {
+		m.devTLS = dev
+		return nil
+	}
+}
+
+// Configuration option to change how long a graceful shutdown waits for active connections.
+func SetDrainTimeout(timeout time.Duration) func(*Milo) error {
+	return func(m *Milo) error {
+		if timeout < 0 {
+			return errors.New("milo: drain timeout can't be negative")
+		}
+		m.drainTimeout = timeout
+		return nil
+	}
+}
+
+// Configuration option to log a table of the registered routes on startup.
+func SetLogRoutes(log bool) func(*Milo) error {
+	return func(m *Milo) error {
+		m.logRoutes = log
+		return nil
+	}
+}
+
+// Configuration option to let POST requests ask for PUT, PATCH or DELETE with the X-HTTP-Method-Override header
+// or a _method field in a url encoded form.  Resources need it for html forms to update and delete.
+func SetMethodOverride(override bool) func(*Milo) error {
+	return func(m *Milo) error {
+		m.methodOverride = override
+		return nil
+	}
+}
+
+// Configuration option to set how paths which differ from a route by a trailing slash, duplicate slashes or case
+// are handled.  Without rules all three are canonicalized.  Redirect and lenient only act on requests which would
+// otherwise be a 404.
+func SetPathPolicy(policy PathPolicy, rules ...PathRule) func(*Milo) error {
+	return func(m *Milo) error {
+		if policy < PathStrict || policy > PathLenient {
+			return fmt.Errorf("milo: unknown path policy %d", policy)
+		}
+		m.pathPolicy = policy
+		m.pathRules = 0
+		for _, rule := range rules {
+			m.pathRules |= rule
+		}
+		if len(rules) == 0 {
+			m.pathRules = PathTrailingSlash | PathDuplicateSlashes | PathCase
+		}
+		// Milo takes over duplicate slashes from gorilla's path cleaning.
+		m.router.SkipClean(true)
+		return nil
+	}
+}
+
+// Configuration option to keep serving for a delay after a shutdown begins while the readiness endpoint reports
+// failing.  The delay counts against the drain timeout.
+func SetShutdownDelay(delay time.Duration) func(*Milo) error {
+	return func(m *Milo) error {
+		if delay < 0 {
+			return errors.New("milo: shutdown delay can't be negative")
+		}
+		m.shutdownDelay = delay
+		return nil
+	}
+}
+
+// Configuration option to change the maximum duration for reading an entire request, including the body.
+// Zero means no timeout.
+func SetReadTimeout(timeout time.Duration) func(*Milo) error {
+	return func(m *Milo) error {
+		if timeout < 0 {
+			return errors.New("milo: read timeout can't be negative")
+		}
+		m.readTimeout = timeout
+		return nil
+	}
+}
+
+// Configuration option to change the maximum duration for reading request headers.
+func SetReadHeaderTimeout(timeout time.Duration) func(*Milo) error {
+	return func(m *Milo) error {
+		if timeout < 0 {
+			return errors.New("milo: read header timeout can't be negative")
+		}
+		m.readHeaderTimeout = timeout
+		return nil
+	}
+}
+
+// Configuration option to change the maximum duration before timing out writes of the response.
+// Zero means no timeout, useful for long running downloads.
+func SetWriteTimeout(timeout time.Duration) func(*Milo) error {
+	return func(m *Milo) error {
+		if timeout < 0 {
+			return errors.New("milo: write timeout can't be negative")
+		}
+		m.writeTimeout = timeout
+		return nil
+	}
+}
+
+// Configuration option to change how long keep-alive connections wait for the next request.
+func SetIdleTimeout(timeout time.Duration) func(*Milo) error {
+	return func(m *Milo) error {
+		if timeout < 0 {
+			return errors.New("milo: idle timeout can't be negative")
+		}
+		m.idleTimeout = timeout
+		return nil
+	}
+}
+
+// Configuration option to change the maximum size of request headers.
+func SetMaxHeaderBytes(size int) func(*Milo) error {
+	return func(m *Milo) error {
+		if size < 0 {
+			return errors.New("milo: max header bytes can't be negative")
+		}
+		m.maxHeaderBytes = size
+		return nil
+	}
+}
+
+// Check the settings which conflict with each other once all options are applied.
+func (m *Milo) validate() error {
+	var errs []error
+	if m.unixSocket != "" && m.portIncrement {
+		errs = append(errs, errors.New("milo: port increment can't be used with a unix socket"))
+	}
+	if m.port == 0 && m.portIncrement {
+		errs = append(errs, errors.New("milo: port increment can't be used with an ephemeral port"))
+	}
+	if m.certFile != "" && m.devTLS {
+		errs = append(errs, errors.New("milo: dev tls can't be used with a certificate file"))
+	}
+	if m.certReload && m.certFile == "" {
+		errs = append(errs, errors.New("milo: tls reload requires a certificate file"))
+	}
+	if m.redirectPort > 0 && !m.tlsEnabled() {
+		errs = append(errs, errors.New("milo: tls redirect requires tls"))
+	}
+	if m.redirectPort > 0 && m.redirectPort == m.port && m.unixSocket == "" {
+		errs = append(errs, fmt.Errorf("milo: tls redirect port %d is the same as the app port", m.redirectPort))
+	}
+	return errors.Join(errs...)
+}
+
+// Check the bind address is empty, an ip or a host name.
+func validBind(bind string) bool {
+	if bind == "" {
+		return true
+	}
+	if net.ParseIP(bind) != nil {
+		return true
+	}
+	if len(bind) > 253 {
+		return false
+	}
+	for _, label := range strings.Split(bind, ".") {
+		if label == "" || len(label) > 63 || strings.HasPrefix(label, "-") || strings.HasSuffix(label, "-") {
+			return false
+		}
+		for _, c := range label {
+			if !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '-') {
+				return false
+			}
+		}
+	}
+	return true
+}
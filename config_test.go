@@ -0,0 +1,76 @@
+package milo_test
+
+import (
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kendellfab/milo"
+)
+
+func TestOptionValidation(t *testing.T) {
+	tests := []struct {
+		name    string
+		opts    []func(*milo.Milo) error
+		wantErr []string
+	}{
+		{name: "defaults"},
+		{name: "host bind", opts: []func(*milo.Milo) error{milo.SetBind("localhost")}},
+		{name: "ipv6 bind", opts: []func(*milo.Milo) error{milo.SetBind("::1")}},
+		{name: "bracketed ipv6 bind", opts: []func(*milo.Milo) error{milo.SetBind("[::1]")}},
+		{name: "bad bind", opts: []func(*milo.Milo) error{milo.SetBind("bad host")}, wantErr: []string{`invalid bind address "bad host"`}},
+		{name: "bracketed host bind", opts: []func(*milo.Milo) error{milo.SetBind("[localhost]")}, wantErr: []string{"invalid bind address"}},
+		{name: "port", opts: []func(*milo.Milo) error{milo.SetPort(70000)}, wantErr: []string{"port 70000 out of range"}},
+		{name: "tls without key", opts: []func(*milo.Milo) error{milo.SetTLS("cert.pem", "")}, wantErr: []string{"tls requires both"}},
+		{name: "negative timeout", opts: []func(*milo.Milo) error{milo.SetReadTimeout(-time.Second)}, wantErr: []string{"read timeout can't be negative"}},
+		{name: "reload without cert", opts: []func(*milo.Milo) error{milo.SetTLSReload(true)}, wantErr: []string{"tls reload requires a certificate file"}},
+		{name: "redirect without tls", opts: []func(*milo.Milo) error{milo.SetTLSRedirect(8080)}, wantErr: []string{"tls redirect requires tls"}},
+		{name: "increment with ephemeral port", opts: []func(*milo.Milo) error{milo.SetPort(0), milo.SetPortInc(true)},
+			wantErr: []string{"port increment can't be used with an ephemeral port"}},
+		{name: "errors joined", opts: []func(*milo.Milo) error{milo.SetPort(-1), milo.SetDrainTimeout(-time.Second), milo.SetTLSReload(true)},
+			wantErr: []string{"port -1 out of range", "drain timeout can't be negative", "tls reload requires a certificate file"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app, err := milo.NewMilo(tt.opts...)
+			if len(tt.wantErr) == 0 {
+				if err != nil {
+					t.Fatal(err)
+				}
+				if app == nil {
+					t.Fatal("expected an app")
+				}
+				return
+			}
+			if err == nil {
+				t.Fatal("expected an error")
+			}
+			if app != nil {
+				t.Error("expected no app with an error")
+			}
+			for _, want := range tt.wantErr {
+				if !strings.Contains(err.Error(), want) {
+					t.Errorf("expected error containing %q, got %v", want, err)
+				}
+			}
+		})
+	}
+}
+
+func TestBracketedBindListens(t *testing.T) {
+	app, err := milo.NewMilo(milo.SetBind("[::1]"), milo.SetPort(0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := app.Listen(); err != nil {
+		if strings.Contains(err.Error(), "missing port") || strings.Contains(err.Error(), "too many colons") {
+			t.Fatal(err)
+		}
+		t.Skip("ipv6 loopback unavailable:", err)
+	}
+	defer closeApp(t, app)
+	if addr, ok := app.Addr().(*net.TCPAddr); !ok || !addr.IP.Equal(net.IPv6loopback) {
+		t.Errorf("expected to listen on the ipv6 loopback, got %v", app.Addr())
+	}
+}
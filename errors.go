@@ -0,0 +1,187 @@
+package milo
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// A handler which returns an error instead of writing its own error response, see RouteE.
+type HandlerFuncE func(w http.ResponseWriter, r *http.Request) error
+
+// Maps an error to a response, returning false when it doesn't handle the error.
+type ErrorMapper func(err error) (*HTTPError, bool)
+
+// An error with the status and public message to respond with.  Err is the internal cause, it is logged but never
+// sent to the client.
+type HTTPError struct {
+	Code    int
+	Message string
+	Err     error
+}
+
+// Create an http error, an empty message uses the status text.
+func NewHTTPError(code int, message string, err error) *HTTPError {
+	return &HTTPError{Code: code, Message: message, Err: err}
+}
+
+func (he *HTTPError) Error() string {
+	if he.Err != nil {
+		return fmt.Sprintf("%d %s: %v", he.Code, he.message(), he.Err)
+	}
+	return fmt.Sprintf("%d %s", he.Code, he.message())
+}
+
+func (he *HTTPError) Unwrap() error {
+	return he.Err
+}
+
+// The public message, the status text when none was given.
+func (he *HTTPError) message() string {
+	if he.Message != "" {
+		return he.Message
+	}
+	return http.StatusText(he.Code)
+}
+
+// Map errors matching the target with errors.Is to a response, such as sql.ErrNoRows to a 404.  An empty message
+// uses the status text.
+func (m *Milo) RegisterError(target error, code int, message string) {
+	m.RegisterErrorMapper(func(err error) (*HTTPError, bool) {
+		if errors.Is(err, target) {
+			return &HTTPError{Code: code, Message: message, Err: err}, true
+		}
+		return nil, false
+	})
+}
+
+// Register a function mapping errors to responses, for matching on error types with errors.As.  Mappers are tried
+// in registration order.
+func (m *Milo) RegisterErrorMapper(mapper ErrorMapper) {
+	m.mu.Lock()
+	m.errorMappers = append(m.errorMappers, mapper)
+	m.mu.Unlock()
+}
+
+// Setup a route whose handler returns an error, errors are mapped to a response through the registered error
+// mappings.  HTTPErrors respond with their own status and anything unmapped is a 500.
+func (m *Milo) RouteE(path string, methods []string, hf HandlerFuncE, mws ...Middleware) *MiloRoute {
+	return m.Route(path, methods, m.errorHandler(hf), mws...)
+}
+
+// Setup a route in the group whose handler returns an error.
+func (g *Group) RouteE(path string, methods []string, hf HandlerFuncE, mws ...Middleware) *MiloRoute {
+	return g.Route(path, methods, g.milo.errorHandler(hf), mws...)
+}
+
+// Adapt an error returning handler, responding to the error it returns.
+func (m *Milo) errorHandler(hf HandlerFuncE) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if err := hf(w, r); err != nil {
+			m.RenderHTTPError(w, r, err)
+		}
+	}
+}
+
+// Respond to an error with the status and message it maps to, as json when the request accepts json.  Handlers
+// which don't use RouteE can call this too.
+func (m *Milo) RenderHTTPError(w http.ResponseWriter, r *http.Request, err error) {
+	he := m.mapError(err)
+	m.logger.LogInterfaces("milo.Error", r.URL.RequestURI(), he.Code, err)
+	if responseStarted(w, r) {
+		// The handler already started its response, all that's left is the log.
+		return
+	}
+	m.renderStatus(w, r, he.Code, he.message())
+}
+
+// Render the pages for an error status with the templates, such as a 404 page using the site layout.  The data has
+// the status, statusText, message, path and request, plus the flashes when a flash base is registered.  Api
+// requests get json instead, and a template which fails falls back to a plain text page.
+func (m *Milo) RegisterErrorTemplate(status int, tpls ...string) {
+	m.mu.Lock()
+	m.errorTemplates[status] = tpls
+	m.mu.Unlock()
+}
+
+// Register the flash base so error pages include the flashes.
+func (m *Milo) RegisterFlashBase(fb *FlashBase) {
+	m.flashBase = fb
+}
+
+// Render the registered error template for the status, false when there isn't one or it fails to render.
+func (m *Milo) renderErrorTemplate(w http.ResponseWriter, r *http.Request, code int, message string) (ok bool) {
+	m.mu.Lock()
+	tpls := m.errorTemplates[code]
+	m.mu.Unlock()
+	if len(tpls) == 0 || m.renderer == nil {
+		return false
+	}
+	defer func() {
+		if err := recover(); err != nil {
+			m.logger.LogInterfaces("milo.ErrorTemplate", code, tpls, err)
+			ok = false
+		}
+	}()
+
+	data := map[string]interface{}{
+		"status":     code,
+		"statusText": http.StatusText(code),
+		"message":    message,
+		"path":       r.URL.Path,
+		"request":    r,
+	}
+	if m.flashBase != nil {
+		data[FlashError], data[FlashSuccess] = m.flashBase.GetFlashes(w, r)
+	}
+	doc, err := m.renderer.renderBytes(r, code, data, tpls...)
+	if err != nil {
+		m.logger.LogInterfaces("milo.ErrorTemplate", code, tpls, err)
+		return false
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(code)
+	w.Write(doc)
+	return true
+}
+
+// Find the response for the error, an HTTPError in the chain wins over the mappers.
+func (m *Milo) mapError(err error) *HTTPError {
+	var he *HTTPError
+	if errors.As(err, &he) {
+		return withStatus(he)
+	}
+	m.mu.Lock()
+	mappers := append([]ErrorMapper{}, m.errorMappers...)
+	m.mu.Unlock()
+	for _, mapper := range mappers {
+		if he, ok := mapper(err); ok && he != nil {
+			return withStatus(he)
+		}
+	}
+	return &HTTPError{Code: http.StatusInternalServerError, Err: err}
+}
+
+// A copy of the error with a valid status code, errors built without one are a 500.
+func withStatus(he *HTTPError) *HTTPError {
+	mapped := *he
+	if mapped.Code < 100 || mapped.Code > 999 {
+		mapped.Code = http.StatusInternalServerError
+	}
+	return &mapped
+}
+
+// Check the request accepts a json response.
+func wantsJSON(r *http.Request) bool {
+	return strings.Contains(r.Header.Get("Accept"), "application/json")
+}
+
+// Write a json error body of the form {"status": 404, "error": "Not Found"}.
+func writeJSONError(w http.ResponseWriter, code int, message string) {
+	data, _ := json.Marshal(map[string]interface{}{"status": code, "error": message})
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	w.Write(data)
+}
@@ -0,0 +1,63 @@
+package milo_test
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/kendellfab/milo"
+	"github.com/kendellfab/milo/milotest"
+)
+
+func TestRouteEMapsErrors(t *testing.T) {
+	app, err := milo.NewMilo()
+	if err != nil {
+		t.Fatal(err)
+	}
+	app.RegisterError(sql.ErrNoRows, http.StatusNotFound, "")
+	app.RouteE("/missing", []string{http.MethodGet}, func(w http.ResponseWriter, r *http.Request) error {
+		return fmt.Errorf("load post: %w", sql.ErrNoRows)
+	})
+	app.RouteE("/forbidden", []string{http.MethodGet}, func(w http.ResponseWriter, r *http.Request) error {
+		return milo.NewHTTPError(http.StatusForbidden, "nope", errors.New("internal detail"))
+	})
+	app.RouteE("/nocode", []string{http.MethodGet}, func(w http.ResponseWriter, r *http.Request) error {
+		return &milo.HTTPError{Message: "no code"}
+	})
+	app.RouteE("/failed", []string{http.MethodGet}, func(w http.ResponseWriter, r *http.Request) error {
+		return errors.New("database down")
+	})
+
+	c := milotest.NewClient(t, app.Handler())
+	c.Get("/missing").AssertStatus(http.StatusNotFound).AssertBodyContains("404 - Not Found.")
+	c.Get("/nocode").AssertStatus(http.StatusInternalServerError)
+	resp := c.Get("/failed").AssertStatus(http.StatusInternalServerError)
+	if resp.Body.String() != "500 - Internal Server Error.\n" {
+		t.Errorf("expected the internal error to stay private, got %q", resp.Body.String())
+	}
+	resp = c.Send(milotest.NewRequest(http.MethodGet, "/forbidden").Header("Accept", "application/json")).
+		AssertStatus(http.StatusForbidden)
+	if resp.Body.String() != `{"error":"nope","status":403}` {
+		t.Errorf("expected a json error, got %q", resp.Body.String())
+	}
+}
+
+func TestRouteEKeepsStartedResponse(t *testing.T) {
+	app, err := milo.NewMilo()
+	if err != nil {
+		t.Fatal(err)
+	}
+	app.RouteE("/partial", []string{http.MethodGet}, func(w http.ResponseWriter, r *http.Request) error {
+		w.Write([]byte("partial"))
+		return errors.New("failed part way")
+	}).Timeout(time.Second)
+
+	c := milotest.NewClient(t, app.Handler())
+	resp := c.Get("/partial").AssertStatus(http.StatusOK)
+	if resp.Body.String() != "partial" {
+		t.Errorf("expected only the partial output, got %q", resp.Body.String())
+	}
+}
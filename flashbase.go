@@ -41,6 +41,17 @@ func (fb *FlashBase) GetFlashes(w http.ResponseWriter, r *http.Request) ([]inter
 	return errFlash, successFlash
 }
 
+// Read the flashes for the request without clearing them from the session.
+func (fb *FlashBase) PeekFlashes(r *http.Request) ([]interface{}, []interface{}) {
+	var errFlash []interface{}
+	var successFlash []interface{}
+	if sess, sessErr := fb.store.Get(r, SessFlash); sessErr == nil {
+		errFlash = sess.Flashes(FlashError)
+		successFlash = sess.Flashes(FlashSuccess)
+	}
+	return errFlash, successFlash
+}
+
 func (fb *FlashBase) setFlashMessage(w http.ResponseWriter, r *http.Request, key, message string) {
 	if sess, sessErr := fb.store.Get(r, SessFlash); sessErr == nil {
 		sess.AddFlash(message, key)
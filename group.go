@@ -0,0 +1,191 @@
+package milo
+
+import (
+	"io/fs"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"golang.org/x/net/websocket"
+)
+
+// A group of routes under a shared path prefix or host with its own before & after middleware stacks, which run
+// inside the global middleware.
+type Group struct {
+	milo             *Milo
+	parent           *Group
+	prefix           string
+	host             string
+	hostTpl          string
+	prefixTpl        string
+	router           *mux.Router
+	matcher          *mux.Route
+	paramTypes       map[string]string
+	notFoundHandler  http.HandlerFunc
+	beforeMiddleware []MiloMiddlware
+	afterMiddleware  []MiloMiddlware
+}
+
+// Create a route group under the prefix, the middleware is added to the group before stack.
+func (m *Milo) Group(prefix string, before ...MiloMiddlware) *Group {
+	tpl, types := parseParamTypes(prefix)
+	g := &Group{milo: m, prefix: prefix, prefixTpl: tpl, paramTypes: types}
+	g.router = m.router.PathPrefix(tpl).Subrouter()
+	return m.addGroup(g, before)
+}
+
+// Create a scoped router for requests to hosts matching the pattern, such as "{subdomain}.example.com".
+// Host variables are available to handlers through mux.Vars and the param helpers.  Routes match in registration
+// order, so register host groups before routes which would match any host.
+func (m *Milo) Host(pattern string, before ...MiloMiddlware) *Group {
+	tpl, types := parseParamTypes(pattern)
+	g := &Group{milo: m, host: pattern, hostTpl: tpl, paramTypes: types}
+	g.router = m.router.Host(tpl).Subrouter()
+	return m.addGroup(g, before)
+}
+
+// Create a nested group under the prefix, its middleware runs after the parent group middleware.
+func (g *Group) Group(prefix string, before ...MiloMiddlware) *Group {
+	tpl, types := parseParamTypes(prefix)
+	child := &Group{milo: g.milo, parent: g, prefix: g.prefix + prefix, prefixTpl: tpl, paramTypes: types}
+	child.router = g.router.PathPrefix(tpl).Subrouter()
+	return g.milo.addGroup(child, before)
+}
+
+// Register a not found handler for requests inside the group which don't match any of its routes.
+func (g *Group) RegisterNotFound(h http.HandlerFunc) {
+	g.notFoundHandler = h
+}
+
+// Add after request middleware to the group middleware stack.
+func (g *Group) RegisterAfter(mw MiloMiddlware) {
+	g.afterMiddleware = append(g.afterMiddleware, mw)
+}
+
+// Add before request middleware to the group middleware stack.
+func (g *Group) RegisterBefore(mw MiloMiddlware) {
+	g.beforeMiddleware = append(g.beforeMiddleware, mw)
+}
+
+// Setup a route in the group to be executed when the path is matched.
+func (g *Group) Route(path string, methods []string, hf http.HandlerFunc, mws ...Middleware) *MiloRoute {
+	return g.milo.handle(g.router.Path, KindRoute, path, g.prefix+path, methods, hf, g, mws)
+}
+
+// Setup a route in the group to be executed when the specific path prefix is matched.
+func (g *Group) PathPrefix(path string, methods []string, hf http.HandlerFunc, mws ...Middleware) *MiloRoute {
+	return g.milo.handle(g.router.PathPrefix, KindPrefix, path, g.prefix+path, methods, hf, g, mws)
+}
+
+// Handling websocket connection inside the group.
+func (g *Group) RouteWebsocket(path string, hf func(ws *websocket.Conn)) *MiloRoute {
+	return g.milo.handleRaw(g.router.Path, KindWebsocket, path, g.prefix+path, websocket.Handler(g.milo.trackWebsocket(hf)), g)
+}
+
+// Handle assets rooted in different directories inside the group.
+func (g *Group) RouteAsset(prefix, dir string) *MiloRoute {
+	return g.milo.handleRaw(g.router.PathPrefix, KindAsset, prefix, g.prefix+prefix, http.FileServer(http.Dir(dir)), g)
+}
+
+// Handle assets rooted in different directories inside the group, strips the group and asset prefix.
+func (g *Group) RouteAssetStripPrefix(prefix, dir string) *MiloRoute {
+	return g.routeAssetStripPrefix(prefix, http.FileServer(http.Dir(dir)))
+}
+
+// Handle assets from a file system inside the group.
+func (g *Group) RouteAssetFS(prefix string, fsys fs.FS) *MiloRoute {
+	return g.milo.handleRaw(g.router.PathPrefix, KindAsset, prefix, g.prefix+prefix, http.FileServer(http.FS(fsys)), g)
+}
+
+// Handle assets from a file system inside the group, strips the group and asset prefix.
+func (g *Group) RouteAssetFSStripPrefix(prefix string, fsys fs.FS) *MiloRoute {
+	return g.routeAssetStripPrefix(prefix, http.FileServer(http.FS(fsys)))
+}
+
+// Register the asset handler so it sees the path below the prefix the request matched, which differs from the
+// prefix text when the group has route variables.
+func (g *Group) routeAssetStripPrefix(prefix string, h http.Handler) *MiloRoute {
+	var mr *MiloRoute
+	mr = g.milo.handleRaw(g.router.PathPrefix, KindAsset, prefix, g.prefix+prefix, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		matched, err := mr.route.URLPath(varPairs(r)...)
+		if err != nil {
+			http.NotFound(w, r)
+			return
+		}
+		http.StripPrefix(matched.Path, h).ServeHTTP(w, r)
+	}), g)
+	return mr
+}
+
+// Handles requests inside the group which don't match any of its routes.
+func (g *Group) notFound(w http.ResponseWriter, r *http.Request) {
+	g.milo.logger.Log("404 - Route not found.  " + r.RequestURI)
+	g.notFoundHandler(w, r)
+}
+
+// The host pattern the group is scoped to, empty when any host matches.
+func (g *Group) hostPattern() string {
+	for group := g; group != nil; group = group.parent {
+		if group.host != "" {
+			return group.host
+		}
+	}
+	return ""
+}
+
+// Check the request falls inside the group's host and prefix, returning the group variables.
+func (g *Group) match(r *http.Request) (map[string]string, bool) {
+	var match mux.RouteMatch
+	if !g.matcher.Match(r, &match) {
+		return nil, false
+	}
+	return match.Vars, true
+}
+
+// The groups from the outermost to this one, empty for a nil group.
+func (g *Group) chain() []*Group {
+	var groups []*Group
+	for group := g; group != nil; group = group.parent {
+		groups = append([]*Group{group}, groups...)
+	}
+	return groups
+}
+
+// Add the group to the registry used for group not found handling.
+func (m *Milo) addGroup(g *Group, before []MiloMiddlware) *Group {
+	g.beforeMiddleware = append([]MiloMiddlware{}, before...)
+	g.afterMiddleware = make([]MiloMiddlware, 0)
+	// A standalone route re-applying the host and prefix matchers of the group chain, built up front as requests
+	// match against it concurrently.
+	g.matcher = mux.NewRouter().NewRoute()
+	for _, group := range g.chain() {
+		if group.hostTpl != "" {
+			g.matcher = g.matcher.Host(group.hostTpl)
+		}
+		if group.prefixTpl != "" {
+			g.matcher = g.matcher.PathPrefix(group.prefixTpl)
+		}
+	}
+	m.mu.Lock()
+	m.groups = append(m.groups, g)
+	m.mu.Unlock()
+	return g
+}
+
+// Find the innermost group with a not found handler which the request falls inside.
+func (m *Milo) notFoundGroup(r *http.Request) (*Group, map[string]string) {
+	m.mu.Lock()
+	groups := append([]*Group{}, m.groups...)
+	m.mu.Unlock()
+
+	var found *Group
+	var foundVars map[string]string
+	for _, g := range groups {
+		if g.notFoundHandler == nil || (found != nil && len(g.chain()) <= len(found.chain())) {
+			continue
+		}
+		if vars, ok := g.match(r); ok {
+			found, foundVars = g, vars
+		}
+	}
+	return found, foundVars
+}
@@ -0,0 +1,27 @@
+package milo_test
+
+import (
+	"net/http"
+	"testing"
+	"testing/fstest"
+
+	"github.com/kendellfab/milo"
+	"github.com/kendellfab/milo/milotest"
+)
+
+func TestGroupAssetsStripMatchedPrefix(t *testing.T) {
+	app, err := milo.NewMilo()
+	if err != nil {
+		t.Fatal(err)
+	}
+	assets := fstest.MapFS{"a.css": &fstest.MapFile{Data: []byte("body{}")}}
+	app.Host("{site:alpha}.example.com").RouteAssetFSStripPrefix("/static/", assets)
+	app.Group("/{tenant}").RouteAssetFSStripPrefix("/static/", assets)
+	app.Group("/typed/{id:int}").Group("/{tenant}").RouteAssetFSStripPrefix("/static/", assets)
+
+	c := milotest.NewClient(t, app.Handler())
+	c.Get("/acme/static/a.css").AssertStatus(http.StatusOK).AssertBodyContains("body{}")
+	c.Get("/typed/7/acme/static/a.css").AssertStatus(http.StatusOK).AssertBodyContains("body{}")
+	c.Get("http://acme.example.com/static/a.css").AssertStatus(http.StatusOK).AssertBodyContains("body{}")
+	c.Get("/acme/static/missing.css").AssertStatus(http.StatusNotFound)
+}
@@ -0,0 +1,191 @@
+package milo
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const (
+	defaultHealthTimeout = 5 * time.Second
+	healthOK             = "ok"
+	healthFail           = "fail"
+)
+
+// A named health check.  Checks run on the readiness endpoint, liveness checks also run on the health endpoint.
+type HealthCheck struct {
+	Name     string
+	Check    func(ctx context.Context) error
+	Timeout  time.Duration // Defaults to 5 seconds.
+	CacheFor time.Duration // Results are reused for this long, zero runs the check on every request.
+	Liveness bool
+}
+
+// The result of a single health check.
+type HealthResult struct {
+	Status   string    `json:"status"`
+	Error    string    `json:"error,omitempty"`
+	Duration string    `json:"duration"`
+	Checked  time.Time `json:"checked"`
+}
+
+// The health endpoint response.
+type HealthReport struct {
+	Status string                  `json:"status"`
+	Checks map[string]HealthResult `json:"checks"`
+}
+
+// A registered check with its cached result.
+type healthEntry struct {
+	HealthCheck
+	result HealthResult
+	sync.Mutex
+}
+
+// Register a health check, checks with the same name replace the earlier one.
+func (m *Milo) RegisterHealthCheck(hc HealthCheck) {
+	if hc.Timeout <= 0 {
+		hc.Timeout = defaultHealthTimeout
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for i, entry := range m.healthChecks {
+		if entry.Name == hc.Name {
+			m.healthChecks[i] = &healthEntry{HealthCheck: hc}
+			return
+		}
+	}
+	m.healthChecks = append(m.healthChecks, &healthEntry{HealthCheck: hc})
+}
+
+// Setup the liveness and readiness endpoints, usually "/healthz" and "/readyz".  Liveness runs the liveness
+// checks, readiness runs every check and fails once a graceful shutdown begins.  An empty path skips the endpoint.
+// The endpoints skip the before, after and route middleware so auth checks or login redirects never answer a probe.
+func (m *Milo) RouteHealth(livePath, readyPath string) {
+	if livePath != "" {
+		m.routeHealth(livePath, false)
+	}
+	if readyPath != "" {
+		m.routeHealth(readyPath, true)
+	}
+}
+
+// Register a health endpoint straight on the router as a bare route, outside the milo middleware pipeline.
+func (m *Milo) routeHealth(path string, readiness bool) *MiloRoute {
+	methods := withHead([]string{http.MethodGet})
+	mr := m.addRoute(&MiloRoute{milo: m, path: path, kind: KindRoute, methods: methods, bare: true})
+	mr.route = m.router.Path(path).Methods(methods...).HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		writeHealth(w, m.Health(r.Context(), readiness))
+	})
+	return mr
+}
+
+// Run the health checks, readiness runs every check and liveness only the liveness checks.
+func (m *Milo) Health(ctx context.Context, readiness bool) HealthReport {
+	m.mu.Lock()
+	entries := make([]*healthEntry, 0, len(m.healthChecks))
+	for _, entry := range m.healthChecks {
+		if readiness || entry.Liveness {
+			entries = append(entries, entry)
+		}
+	}
+	m.mu.Unlock()
+
+	report := HealthReport{Status: healthOK, Checks: make(map[string]HealthResult)}
+	results := make([]HealthResult, len(entries))
+	var wg sync.WaitGroup
+	for i, entry := range entries {
+		wg.Add(1)
+		go func(i int, entry *healthEntry) {
+			defer wg.Done()
+			results[i] = entry.run(ctx)
+		}(i, entry)
+	}
+	wg.Wait()
+
+	for i, entry := range entries {
+		report.Checks[entry.Name] = results[i]
+		if results[i].Status != healthOK {
+			report.Status = healthFail
+		}
+	}
+	if readiness && m.isShuttingDown() {
+		report.Status = healthFail
+		report.Checks["shutdown"] = HealthResult{Status: healthFail, Error: "shutting down", Checked: time.Now()}
+	}
+	return report
+}
+
+// Run the check, or reuse the cached result while it is fresh.
+func (he *healthEntry) run(ctx context.Context) HealthResult {
+	he.Lock()
+	defer he.Unlock()
+	if he.CacheFor > 0 && time.Since(he.result.Checked) < he.CacheFor {
+		return he.result
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, he.Timeout)
+	defer cancel()
+	start := time.Now()
+	done := make(chan error, 1)
+	go func() {
+		defer func() {
+			if err := recover(); err != nil {
+				done <- errors.New("health check panicked")
+			}
+		}()
+		done <- he.Check(ctx)
+	}()
+
+	var err error
+	select {
+	case err = <-done:
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+
+	result := HealthResult{Status: healthOK, Duration: time.Since(start).String(), Checked: time.Now()}
+	if err != nil {
+		result.Status = healthFail
+		result.Error = err.Error()
+	}
+	he.result = result
+	return result
+}
+
+// Write the report as json, failing reports get a 503.
+func writeHealth(w http.ResponseWriter, report HealthReport) {
+	data, err := json.Marshal(report)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	if report.Status != healthOK {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	} else {
+		w.WriteHeader(http.StatusOK)
+	}
+	w.Write(data)
+}
+
+// Something which can be pinged, such as a *sql.DB.
+type Pinger interface {
+	PingContext(ctx context.Context) error
+}
+
+// A health check which pings the database or other pinger.
+func PingCheck(p Pinger) func(ctx context.Context) error {
+	return p.PingContext
+}
+
+// A health check which makes sure the templates parse, caching them when the renderer caches templates.
+func TemplateCheck(r *Renderer, tpls ...string) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		return r.Precompile(tpls...)
+	}
+}
@@ -0,0 +1,24 @@
+//go:build linux || darwin
+
+package milo
+
+import (
+	"context"
+	"fmt"
+	"syscall"
+)
+
+// A health check which fails when the free space on the filesystem holding the path drops below minFree bytes.
+func DiskSpaceCheck(path string, minFree uint64) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		var st syscall.Statfs_t
+		if err := syscall.Statfs(path, &st); err != nil {
+			return err
+		}
+		free := uint64(st.Bavail) * uint64(st.Bsize)
+		if free < minFree {
+			return fmt.Errorf("%d bytes free on %s, want at least %d", free, path, minFree)
+		}
+		return nil
+	}
+}
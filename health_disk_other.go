@@ -0,0 +1,16 @@
+//go:build !linux && !darwin
+
+package milo
+
+import (
+	"context"
+	"errors"
+)
+
+// A health check which fails when the free space on the filesystem holding the path drops below minFree bytes.
+// Not supported on this platform, the check always fails.
+func DiskSpaceCheck(path string, minFree uint64) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		return errors.New("disk space check not supported on this platform")
+	}
+}
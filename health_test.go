@@ -0,0 +1,37 @@
+package milo_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/kendellfab/milo"
+	"github.com/kendellfab/milo/milotest"
+)
+
+func TestHealthSkipsBeforeMiddleware(t *testing.T) {
+	app, err := milo.NewMilo()
+	if err != nil {
+		t.Fatal(err)
+	}
+	app.RegisterBefore(func(w http.ResponseWriter, r *http.Request) bool {
+		http.Redirect(w, r, "/login", http.StatusSeeOther)
+		return false
+	})
+	app.RegisterHealthCheck(milo.HealthCheck{Name: "live", Liveness: true, Check: func(ctx context.Context) error {
+		return nil
+	}})
+	app.RegisterHealthCheck(milo.HealthCheck{Name: "db", Check: func(ctx context.Context) error {
+		return errors.New("down")
+	}})
+	app.RouteHealth("/healthz", "/readyz")
+	app.Route("/private", []string{http.MethodGet}, okHandler)
+
+	c := milotest.NewClient(t, app.Handler())
+	c.Get("/healthz").AssertStatus(http.StatusOK).AssertBodyContains(`"status":"ok"`)
+	c.Get("/readyz").AssertStatus(http.StatusServiceUnavailable).AssertBodyContains(`"error":"down"`)
+	c.Send(milotest.NewRequest(http.MethodHead, "/healthz")).AssertStatus(http.StatusOK)
+	c.Send(milotest.NewRequest(http.MethodPost, "/healthz")).AssertStatus(http.StatusMethodNotAllowed)
+	c.Get("/private").AssertRedirect("/login")
+}
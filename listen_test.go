@@ -0,0 +1,72 @@
+package milo_test
+
+import (
+	"context"
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/kendellfab/milo"
+)
+
+// Run the app with a cancelled context so it closes the bound listeners.
+func closeApp(t *testing.T, app *milo.Milo) {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := app.RunContext(ctx); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestListenEphemeralPort(t *testing.T) {
+	app, err := milo.NewMilo(milo.SetBind("127.0.0.1"), milo.SetPort(0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if app.Addr() != nil {
+		t.Fatal("expected no address before Listen")
+	}
+	if err := app.Listen(); err != nil {
+		t.Fatal(err)
+	}
+	defer closeApp(t, app)
+
+	addr, ok := app.Addr().(*net.TCPAddr)
+	if !ok || addr.Port == 0 {
+		t.Fatalf("expected a bound tcp port, got %v", app.Addr())
+	}
+	if !strings.Contains(app.String(), "Addr: "+addr.String()) {
+		t.Errorf("expected String to include the bound address, got %q", app.String())
+	}
+}
+
+func TestListenWalksPastUsedPort(t *testing.T) {
+	used, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer used.Close()
+	port := used.Addr().(*net.TCPAddr).Port
+
+	strict, err := milo.NewMilo(milo.SetBind("127.0.0.1"), milo.SetPort(port))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := strict.Listen(); err == nil {
+		closeApp(t, strict)
+		t.Fatal("expected binding a used port to fail without port increment")
+	}
+
+	app, err := milo.NewMilo(milo.SetBind("127.0.0.1"), milo.SetPort(port), milo.SetPortInc(true))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := app.Listen(); err != nil {
+		t.Fatal(err)
+	}
+	defer closeApp(t, app)
+	if got := app.Addr().(*net.TCPAddr).Port; got <= port {
+		t.Errorf("expected a port above %d, got %d", port, got)
+	}
+}
@@ -0,0 +1,133 @@
+package milo
+
+import (
+	"errors"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+)
+
+const (
+	listenFdsStart       = 3
+	envListenFds         = "MILO_LISTEN_FDS"
+	envListenFdNames     = "MILO_LISTEN_FDNAMES"
+	mainListenerName     = "milo"
+	redirectListenerName = "redirect"
+)
+
+// An additional listener served alongside the main application listener.
+type listenerConfig struct {
+	name    string
+	network string
+	addr    string
+	handler http.Handler
+	ln      net.Listener
+}
+
+// Serve the handler on an additional listener for the life of the application, such as an internal admin port
+// with its own routes.  The network and address are passed to net.Listen, a "unix" network serves on a socket path.
+// With socket activation a systemd socket whose LISTEN_FDNAMES entry matches the name is used instead.
+func (m *Milo) AddListener(name, network, addr string, h http.Handler) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.listeners = append(m.listeners, &listenerConfig{name: name, network: network, addr: addr, handler: h})
+}
+
+// Assign passed in sockets.  Sockets named after an added listener go to that listener, the redirect socket to the
+// https redirect server, the first of the rest becomes the main application listener and any others also serve
+// the application.
+func (m *Milo) assignListeners(passed []net.Listener, names []string) {
+	for i, ln := range passed {
+		if lc := m.findListener(names[i]); lc != nil && lc.ln == nil {
+			lc.ln = ln
+			continue
+		}
+		if names[i] == redirectListenerName && m.redirectListener == nil {
+			m.redirectListener = ln
+			continue
+		}
+		if m.listener == nil {
+			m.listener = ln
+			continue
+		}
+		m.listeners = append(m.listeners, &listenerConfig{name: names[i], handler: m.Handler(), ln: ln})
+	}
+}
+
+// Find an added listener by name.
+func (m *Milo) findListener(name string) *listenerConfig {
+	if name == "" {
+		return nil
+	}
+	for _, lc := range m.listeners {
+		if lc.name == name {
+			return lc
+		}
+	}
+	return nil
+}
+
+// Listeners passed in from systemd socket activation along with their LISTEN_FDNAMES.
+func systemdListeners() ([]net.Listener, []string, error) {
+	pid, err := strconv.Atoi(os.Getenv("LISTEN_PID"))
+	if err != nil || pid != os.Getpid() {
+		return nil, nil, nil
+	}
+	defer os.Unsetenv("LISTEN_PID")
+	return envListeners("LISTEN_FDS", "LISTEN_FDNAMES")
+}
+
+// Listeners handed over from a parent process during a graceful restart.
+func inheritedListeners() ([]net.Listener, []string, error) {
+	return envListeners(envListenFds, envListenFdNames)
+}
+
+// Build listeners from the file descriptors described by the count & names environment variables.  The variables
+// are unset so child processes don't think the sockets were passed to them.
+func envListeners(countKey, namesKey string) ([]net.Listener, []string, error) {
+	count, err := strconv.Atoi(os.Getenv(countKey))
+	if err != nil || count < 1 {
+		return nil, nil, nil
+	}
+	names := strings.Split(os.Getenv(namesKey), ":")
+	os.Unsetenv(countKey)
+	os.Unsetenv(namesKey)
+
+	listeners := make([]net.Listener, 0, count)
+	listenerNames := make([]string, 0, count)
+	for i := 0; i < count; i++ {
+		name := ""
+		if i < len(names) {
+			name = names[i]
+		}
+		f := os.NewFile(uintptr(listenFdsStart+i), name)
+		ln, err := net.FileListener(f)
+		f.Close()
+		if err != nil {
+			for _, open := range listeners {
+				open.Close()
+			}
+			return nil, nil, err
+		}
+		listeners = append(listeners, ln)
+		listenerNames = append(listenerNames, name)
+	}
+	return listeners, listenerNames, nil
+}
+
+// Listen on a unix socket, removing a stale socket file left behind by a previous process.
+func listenUnix(path string) (net.Listener, error) {
+	if info, err := os.Stat(path); err == nil {
+		if info.Mode()&os.ModeSocket == 0 {
+			return nil, errors.New("milo: unix socket path exists and is not a socket: " + path)
+		}
+		if conn, err := net.Dial("unix", path); err == nil {
+			conn.Close()
+			return nil, errors.New("milo: unix socket is in use: " + path)
+		}
+		os.Remove(path)
+	}
+	return net.Listen("unix", path)
+}
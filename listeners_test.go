@@ -0,0 +1,53 @@
+//go:build unix
+
+package milo_test
+
+import (
+	"net"
+	"net/http"
+	"path/filepath"
+	"testing"
+
+	"github.com/kendellfab/milo"
+)
+
+func TestListenReplacesStaleUnixSocket(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "admin.sock")
+	stale, err := net.Listen("unix", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Leave the socket file behind, as a crashed process would.
+	stale.(*net.UnixListener).SetUnlinkOnClose(false)
+	stale.Close()
+
+	app, err := milo.NewMilo(milo.SetBind("127.0.0.1"), milo.SetPort(0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	app.AddListener("admin", "unix", path, http.NotFoundHandler())
+	if err := app.Listen(); err != nil {
+		t.Fatalf("expected the stale socket to be replaced, got %v", err)
+	}
+	app.Shutdown(t.Context())
+}
+
+func TestListenClosesListenersOnFailure(t *testing.T) {
+	taken, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer taken.Close()
+
+	app, err := milo.NewMilo(milo.SetBind("127.0.0.1"), milo.SetPort(0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	app.AddListener("admin", "tcp", taken.Addr().String(), http.NotFoundHandler())
+	if err := app.Listen(); err == nil {
+		t.Fatal("expected the taken address to fail")
+	}
+	if addr := app.Addr(); addr != nil {
+		t.Errorf("expected the main listener to be closed, still bound to %s", addr)
+	}
+}
@@ -0,0 +1,115 @@
+package milo
+
+import (
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/gorilla/mux"
+)
+
+// Handles requests whose path matches a route but not its methods.  OPTIONS requests are answered with the
+// allowed methods, everything else gets a 405 with the Allow header set.
+func (m *Milo) methodNotAllowed(w http.ResponseWriter, r *http.Request) {
+	allowed := m.allowedMethods(r)
+	w.Header().Set("Allow", strings.Join(allowed, ", "))
+	if r.Method == http.MethodOptions {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	m.logger.Log("405 - Method not allowed.  " + r.Method + " " + r.RequestURI)
+	if m.methodNotAllowedHandler == nil {
+		m.renderStatus(w, r, http.StatusMethodNotAllowed, "MILO: Method not allowed")
+	} else {
+		m.methodNotAllowedHandler(w, r)
+	}
+}
+
+// The methods of the routes which match the request path, OPTIONS is always allowed.
+func (m *Milo) allowedMethods(r *http.Request) []string {
+	routes, _ := m.pathRoutes(r)
+	seen := map[string]bool{http.MethodOptions: true}
+	for _, mr := range routes {
+		for _, method := range mr.methods {
+			seen[strings.ToUpper(method)] = true
+		}
+	}
+
+	allowed := make([]string, 0, len(seen))
+	for method := range seen {
+		allowed = append(allowed, method)
+	}
+	sort.Strings(allowed)
+	return allowed
+}
+
+// The routes which match the request path with one of their own methods, in registration order, along with the
+// variables of the first one.
+func (m *Milo) pathRoutes(r *http.Request) ([]*MiloRoute, map[string]string) {
+	m.mu.Lock()
+	routes := append([]*MiloRoute{}, m.routes...)
+	m.mu.Unlock()
+
+	matched := make([]*MiloRoute, 0)
+	var vars map[string]string
+	for _, mr := range routes {
+		if mr.route == nil {
+			continue
+		}
+		for _, method := range mr.methods {
+			probe := r.Clone(r.Context())
+			probe.Method = strings.ToUpper(method)
+			if match, ok := mr.match(probe); ok {
+				if len(matched) == 0 {
+					vars = match.Vars
+				}
+				matched = append(matched, mr)
+				break
+			}
+		}
+	}
+	return matched, vars
+}
+
+// The first route matching the request path, so its group middleware runs for automatic OPTIONS and 405
+// responses.
+func (m *Milo) methodRoute(r *http.Request) (*MiloRoute, map[string]string) {
+	routes, vars := m.pathRoutes(r)
+	if len(routes) == 0 {
+		return nil, nil
+	}
+	return routes[0], vars
+}
+
+// Add HEAD to the methods of GET routes, net/http drops the body for HEAD requests.
+func withHead(methods []string) []string {
+	hasGet, hasHead := false, false
+	for _, method := range methods {
+		switch strings.ToUpper(method) {
+		case http.MethodGet:
+			hasGet = true
+		case http.MethodHead:
+			hasHead = true
+		}
+	}
+	if hasGet && !hasHead {
+		return append(append([]string{}, methods...), http.MethodHead)
+	}
+	return methods
+}
+
+// Match the request against the route, or the subtree route of a mount.
+func (mr *MiloRoute) match(r *http.Request) (mux.RouteMatch, bool) {
+	var match mux.RouteMatch
+	if mr.route.Match(r, &match) {
+		return match, true
+	}
+	if mr.subtree != nil {
+		match = mux.RouteMatch{}
+		if mr.subtree.Match(r, &match) {
+			return match, true
+		}
+	}
+	return match, false
+}
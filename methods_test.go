@@ -0,0 +1,53 @@
+package milo_test
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/kendellfab/milo"
+	"github.com/kendellfab/milo/milotest"
+)
+
+func okHandler(w http.ResponseWriter, r *http.Request) {
+	w.Write([]byte("ok"))
+}
+
+func TestMethodNotAllowedSetsAllow(t *testing.T) {
+	app, err := milo.NewMilo()
+	if err != nil {
+		t.Fatal(err)
+	}
+	app.Route("/items", []string{http.MethodGet}, okHandler)
+	app.Route("/items", []string{http.MethodPost}, okHandler)
+
+	c := milotest.NewClient(t, app.Handler())
+	resp := c.Send(milotest.NewRequest(http.MethodDelete, "/items")).AssertStatus(http.StatusMethodNotAllowed)
+	if got := resp.Header().Get("Allow"); got != "GET, HEAD, OPTIONS, POST" {
+		t.Errorf("expected Allow of GET, HEAD, OPTIONS, POST, got %q", got)
+	}
+	c.Send(milotest.NewRequest(http.MethodHead, "/items")).AssertStatus(http.StatusOK)
+	c.Send(milotest.NewRequest(http.MethodOptions, "/items")).AssertStatus(http.StatusNoContent)
+	c.Send(milotest.NewRequest(http.MethodDelete, "/missing")).AssertStatus(http.StatusNotFound)
+}
+
+func TestOptionsRunsGroupMiddleware(t *testing.T) {
+	app, err := milo.NewMilo()
+	if err != nil {
+		t.Fatal(err)
+	}
+	cors := func(w http.ResponseWriter, r *http.Request) bool {
+		w.Header().Set("Access-Control-Allow-Origin", "*")
+		return true
+	}
+	app.Group("/api", cors).Route("/items", []string{http.MethodGet}, okHandler)
+
+	c := milotest.NewClient(t, app.Handler())
+	resp := c.Send(milotest.NewRequest(http.MethodOptions, "/api/items")).AssertStatus(http.StatusNoContent)
+	if resp.Header().Get("Access-Control-Allow-Origin") != "*" {
+		t.Error("expected the group middleware to run for OPTIONS")
+	}
+	resp = c.Send(milotest.NewRequest(http.MethodPut, "/api/items")).AssertStatus(http.StatusMethodNotAllowed)
+	if resp.Header().Get("Access-Control-Allow-Origin") != "*" {
+		t.Error("expected the group middleware to run for a 405")
+	}
+}
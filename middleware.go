@@ -0,0 +1,46 @@
+package milo
+
+import (
+	"net/http"
+)
+
+// Standard net/http middleware, used for the Use stack and route middleware.  Milo middleware can be mixed in with
+// FromMiloMiddleware.
+type Middleware func(http.Handler) http.Handler
+
+// Add standard middleware to the global stack.  It wraps the whole route pipeline, before & after middleware
+// included, so request context and writer changes reach everything after it.
+func (m *Milo) Use(mws ...Middleware) {
+	m.middleware = append(m.middleware, mws...)
+}
+
+// Adapt milo middleware for a standard middleware stack, the next handler runs when it returns true.
+func FromMiloMiddleware(mw MiloMiddlware) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if mw(w, r) {
+				next.ServeHTTP(w, r)
+			}
+		})
+	}
+}
+
+// Adapt standard middleware for the before stacks, it returns true when the middleware calls the next handler.
+// Changes the middleware makes to the request or writer aren't passed on, add it with Use or to a route for that.
+func ToMiloMiddleware(mw Middleware) MiloMiddlware {
+	return func(w http.ResponseWriter, r *http.Request) bool {
+		called := false
+		mw(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {
+			called = true
+		})).ServeHTTP(w, r)
+		return called
+	}
+}
+
+// Wrap the handler in the middleware, the first middleware is the outermost.
+func wrapMiddleware(h http.Handler, mws []Middleware) http.Handler {
+	for i := len(mws) - 1; i >= 0; i-- {
+		h = mws[i](h)
+	}
+	return h
+}
@@ -1,56 +1,142 @@
 package milo
 
 import (
+	"errors"
 	"fmt"
+	"io/fs"
+	"net"
 	"net/http"
-	"os"
-	"strings"
+	"sync"
 	"time"
 
-	"log"
-
 	"github.com/gorilla/mux"
 	"golang.org/x/net/websocket"
 )
 
 const (
 	BIND_ERR = "bind: address already in use"
+	maxPort  = 65535
 )
 
 type MiloMiddlware func(w http.ResponseWriter, r *http.Request) bool
 
 // This is the default application.
 type Milo struct {
-	bind                string
-	port                int
-	portIncrement       bool
-	router              *mux.Router
-	subRoutes           map[string]*mux.Router
-	logger              MiloLogger
-	beforeMiddleware    []MiloMiddlware
-	afterMiddleware     []MiloMiddlware
-	defaultErrorHandler http.HandlerFunc
-	notFoundHandler     http.HandlerFunc
-}
-
-// Create a new milo app.  Uses the config object.
+	bind                    string
+	port                    int
+	portIncrement           bool
+	router                  *mux.Router
+	subRoutes               map[string]*mux.Router
+	routes                  []*MiloRoute
+	groups                  []*Group
+	routeNames              map[string]*MiloRoute
+	routeErrors             []error
+	logger                  MiloLogger
+	middleware              []Middleware
+	beforeMiddleware        []MiloMiddlware
+	afterMiddleware         []MiloMiddlware
+	defaultErrorHandler     http.HandlerFunc
+	errorTemplates          map[int][]string
+	flashBase               *FlashBase
+	errorMappers            []ErrorMapper
+	notFoundHandler         http.HandlerFunc
+	methodNotAllowedHandler http.HandlerFunc
+	renderer                *Renderer
+	readTimeout             time.Duration
+	readHeaderTimeout       time.Duration
+	writeTimeout            time.Duration
+	idleTimeout             time.Duration
+	maxHeaderBytes          int
+	drainTimeout            time.Duration
+	shutdownDelay           time.Duration
+	logRoutes               bool
+	methodOverride          bool
+	pathPolicy              PathPolicy
+	pathRules               PathRule
+	certFile                string
+	keyFile                 string
+	certReload              bool
+	devTLS                  bool
+	redirectPort            int
+	unixSocket              string
+	socketActivation        bool
+	gracefulRestart         bool
+	listener                net.Listener
+	redirectListener        net.Listener
+	listeners               []*listenerConfig
+	servers                 []*http.Server
+	healthChecks            []*healthEntry
+	shuttingDown            bool
+	shutdownDone            chan struct{}
+	shutdownOnce            sync.Once
+	websockets              map[*websocket.Conn]struct{}
+	websocketWg             sync.WaitGroup
+	mu                      sync.Mutex
+}
+
+// Create a new milo app.  Uses the config object, invalid options are fatal.
 func NewMiloApp(opts ...func(*Milo) error) *Milo {
+	milo, err := NewMilo(opts...)
+	if err != nil {
+		newDefaultLogger().LogFatal(err)
+	}
+	return milo
+}
+
+// Create a new milo app, returning the errors from every invalid option and conflicting setting together.
+func NewMilo(opts ...func(*Milo) error) (*Milo, error) {
 	milo := &Milo{
 		router:           mux.NewRouter(),
 		subRoutes:        make(map[string]*mux.Router),
+		routes:           make([]*MiloRoute, 0),
+		routeNames:       make(map[string]*MiloRoute),
+		errorTemplates:   make(map[int][]string),
 		logger:           newDefaultLogger(),
 		beforeMiddleware: make([]MiloMiddlware, 0),
 		afterMiddleware:  make([]MiloMiddlware, 0),
+		shutdownDone:     make(chan struct{}),
+		websockets:       make(map[*websocket.Conn]struct{}),
 	}
-	milo.router.NotFoundHandler = milo
+	milo.router.NotFoundHandler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hf := milo.notFound
+		g, vars := milo.notFoundGroup(r)
+		if g != nil {
+			r = mux.SetURLVars(r, vars)
+			hf = g.notFound
+		}
+		milo.runRoute(w, r, hf, r.URL.Path, g)
+	})
+	milo.router.MethodNotAllowedHandler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mr, vars := milo.methodRoute(r)
+		if mr == nil {
+			milo.runRoute(w, r, milo.methodNotAllowed, r.URL.Path, nil)
+			return
+		}
+		r = mux.SetURLVars(r, vars)
+		if mr.bare {
+			milo.methodNotAllowed(w, r)
+			return
+		}
+		milo.runRoute(w, r, milo.methodNotAllowed, r.URL.Path, mr.group)
+	})
 	milo.port = 7000
+	milo.drainTimeout = 15 * time.Second
+	milo.readTimeout = 10 * time.Second
+	milo.writeTimeout = 10 * time.Second
+
+	var errs []error
 	for _, opt := range opts {
-		err := opt(milo)
-		if err != nil {
-			milo.logger.LogFatal(err)
+		if err := opt(milo); err != nil {
+			errs = append(errs, err)
 		}
 	}
-	return milo
+	if err := milo.validate(); err != nil {
+		errs = append(errs, err)
+	}
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+	return milo, nil
 }
 
 // Add after request middleware to the global middleware stack.
@@ -68,45 +154,41 @@ func (m *Milo) RegisterDefaultErrorHandler(h http.HandlerFunc) {
 	m.defaultErrorHandler = h
 }
 
+// Register the renderer used for the responses milo writes itself, such as timeouts.  The renderer builds
+// named route urls through the app, for the url template function and RedirectRoute.
+func (m *Milo) RegisterRenderer(r *Renderer) {
+	m.renderer = r
+	r.RegisterURLBuilder(m)
+}
+
 // Register your own implementation of the milo logger.
 func (m *Milo) RegisterLogger(l MiloLogger) {
 	m.logger = l
 }
 
+// Register a method not allowed handler so you can capture 405 errors, the Allow header is already set.
+func (m *Milo) RegisterMethodNotAllowed(h http.HandlerFunc) {
+	m.methodNotAllowedHandler = h
+}
+
 // Register a not found handler so you can capture 404 errors.
 func (m *Milo) RegisterNotFound(h http.HandlerFunc) {
 	m.notFoundHandler = h
 }
 
-// Setup a route to be executed when the path is matched, uses the gorilla mux router.
-func (m *Milo) Route(path string, methods []string, hf http.HandlerFunc) {
-
-	fn := func(w http.ResponseWriter, r *http.Request) {
-		m.runRoute(w, r, hf, path)
-	}
-
-	if methods != nil {
-		m.router.Path(path).Methods(methods...).HandlerFunc(fn)
-	} else {
-		m.router.Path(path).HandlerFunc(fn)
-	}
+// Setup a route to be executed when the path is matched, uses the gorilla mux router.  The middleware wraps the
+// handler, inside the before middleware.
+func (m *Milo) Route(path string, methods []string, hf http.HandlerFunc, mws ...Middleware) *MiloRoute {
+	return m.handle(m.router.Path, KindRoute, path, path, methods, hf, nil, mws)
 }
 
 // Setup a route to be executed when the specific path prefix is matched, uses the gorilla mux router.
-func (m *Milo) PathPrefix(path string, methods []string, hf http.HandlerFunc) {
-	fn := func(w http.ResponseWriter, r *http.Request) {
-		m.runRoute(w, r, hf, path)
-	}
-
-	if methods != nil {
-		m.router.PathPrefix(path).Methods(methods...).HandlerFunc(fn)
-	} else {
-		m.router.PathPrefix(path).HandlerFunc(fn)
-	}
+func (m *Milo) PathPrefix(path string, methods []string, hf http.HandlerFunc, mws ...Middleware) *MiloRoute {
+	return m.handle(m.router.PathPrefix, KindPrefix, path, path, methods, hf, nil, mws)
 }
 
 // Setup sub routes for more efficient routing of requests inside of gorilla mux.
-func (m *Milo) SubRoute(prefix, path string, methods []string, hf http.HandlerFunc) {
+func (m *Milo) SubRoute(prefix, path string, methods []string, hf http.HandlerFunc, mws ...Middleware) *MiloRoute {
 	var subRouter *mux.Router
 	var ok bool
 
@@ -116,114 +198,143 @@ func (m *Milo) SubRoute(prefix, path string, methods []string, hf http.HandlerFu
 		m.subRoutes[prefix] = subRouter
 	}
 
+	return m.handle(subRouter.Path, KindRoute, path, prefix+path, methods, hf, nil, mws)
+}
+
+// Register the handler on a new mux route for the template so it runs through the milo pipeline.  Typed route
+// variables like {id:int} only match values of their type.
+func (m *Milo) handle(newRoute func(tpl string) *mux.Route, kind, tpl, path string, methods []string, hf http.HandlerFunc, g *Group, mws []Middleware) *MiloRoute {
+	tpl, types := parseParamTypes(tpl)
+	methods = withHead(methods)
+	mr := m.addRoute(&MiloRoute{milo: m, path: path, kind: kind, methods: methods, group: g, paramTypes: types, middleware: mws})
+	wrapped := wrapMiddleware(m.timeoutHandler(mr, hf), mws)
+	checked := m.paramHandler(mr, g, wrapped.ServeHTTP)
 	fn := func(w http.ResponseWriter, r *http.Request) {
-		m.runRoute(w, r, hf, prefix+path)
+		m.runRoute(w, r, checked, path, g)
 	}
 
+	route := newRoute(tpl)
 	if methods != nil {
-		subRouter.Path(path).Methods(methods...).HandlerFunc(fn)
-	} else {
-		subRouter.Path(path).HandlerFunc(fn)
+		route = route.Methods(methods...)
 	}
+	mr.route = route.HandlerFunc(fn)
+	return mr
 }
 
-// Handling websocket connection.
-func (m *Milo) RouteWebsocket(path string, hf func(ws *websocket.Conn)) {
-	m.router.Path(path).Handler(websocket.Handler(hf))
+// Handling websocket connection.  Connections are tracked so a graceful shutdown can wait on them.
+func (m *Milo) RouteWebsocket(path string, hf func(ws *websocket.Conn)) *MiloRoute {
+	return m.handleRaw(m.router.Path, KindWebsocket, path, path, websocket.Handler(m.trackWebsocket(hf)), nil)
 }
 
 // Handle assets rooted in different directories.
-func (m *Milo) RouteAsset(prefix, dir string) {
-	m.router.PathPrefix(prefix).Handler(http.FileServer(http.Dir(dir)))
+func (m *Milo) RouteAsset(prefix, dir string) *MiloRoute {
+	return m.handleRaw(m.router.PathPrefix, KindAsset, prefix, prefix, http.FileServer(http.Dir(dir)), nil)
 }
 
 // Handle assets rooted in different directories, strips prefix.
-func (m *Milo) RouteAssetStripPrefix(prefix, dir string) {
-	m.router.PathPrefix(prefix).Handler(http.StripPrefix(prefix, http.FileServer(http.Dir(dir))))
-}
-
-// Binds and runs the application on the given config port.
-func (m *Milo) Run() {
-	port := m.port
-	if m.portIncrement {
-		for {
-			log.Println("Connection:", m.getConnectionString())
-			srv := m.getHTTPServer()
-			err := srv.ListenAndServe()
-			if err != nil {
-				if strings.Contains(err.Error(), BIND_ERR) {
-					port++
-					time.Sleep(100 * time.Millisecond)
-				} else {
-					m.logger.LogError(err)
-					os.Exit(2)
-				}
-			}
-		}
-	} else {
-		log.Println("Connection:", m.getConnectionString())
-		srv := m.getHTTPServer()
-		if err := srv.ListenAndServe(); err != nil {
-			m.logger.LogError(err)
-		}
-	}
+func (m *Milo) RouteAssetStripPrefix(prefix, dir string) *MiloRoute {
+	return m.handleRaw(m.router.PathPrefix, KindAsset, prefix, prefix, http.StripPrefix(prefix, http.FileServer(http.Dir(dir))), nil)
 }
 
-func (m *Milo) getHTTPServer() *http.Server {
-	return &http.Server{
-		Addr:         m.getConnectionString(),
-		Handler:      m.router,
-		ReadTimeout:  10 * time.Second,
-		WriteTimeout: 10 * time.Second,
-	}
+// Handle assets from a file system, such as an embed.FS.  Use fs.Sub to serve a directory inside it.
+func (m *Milo) RouteAssetFS(prefix string, fsys fs.FS) *MiloRoute {
+	return m.handleRaw(m.router.PathPrefix, KindAsset, prefix, prefix, http.FileServer(http.FS(fsys)), nil)
+}
+
+// Handle assets from a file system, strips prefix.
+func (m *Milo) RouteAssetFSStripPrefix(prefix string, fsys fs.FS) *MiloRoute {
+	return m.handleRaw(m.router.PathPrefix, KindAsset, prefix, prefix, http.StripPrefix(prefix, http.FileServer(http.FS(fsys))), nil)
+}
+
+// Register a websocket or asset handler so it runs through the milo pipeline, unless the route is marked bare.
+func (m *Milo) handleRaw(newRoute func(tpl string) *mux.Route, kind, tpl, path string, h http.Handler, g *Group) *MiloRoute {
+	mr := m.addRoute(&MiloRoute{milo: m, path: path, kind: kind, group: g})
+	mr.route = newRoute(tpl).HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if mr.bare {
+			h.ServeHTTP(w, r)
+			return
+		}
+		m.runRoute(w, r, h.ServeHTTP, path, g)
+	})
+	return mr
 }
 
 // Internal handler for running the route, that way different functions can be exposed but all handled the same.
-func (m *Milo) runRoute(w http.ResponseWriter, r *http.Request, hf http.HandlerFunc, path string) {
+// After middleware runs whatever the outcome, once middleware answers the request itself or the handler panics too.
+func (m *Milo) runRoute(w http.ResponseWriter, r *http.Request, hf http.HandlerFunc, path string, g *Group) {
+	w, r = wrapResponse(w, r)
+	// Recovers panics from the after middleware.
+	defer handleError(m, w, r)
+	defer m.runAfterMiddlware(w, r, g)
+	// Recovers panics from the handler and the rest of the middleware, so the after middleware sees the error status.
 	defer handleError(m, w, r)
 	// Writing out a request log
 	m.logger.LogInterfaces("Path:", path)
-	shouldContinue := m.runBeforeMiddleware(w, r)
-	// Something happend in the global middleware and we don't want to continue
-	// This is under the assumption that the middleware handled everything.
-	if !shouldContinue {
-		return
-	}
-	// Call registered handler
-	hf(w, r)
-	m.runAfterMiddlware(w, r)
+	pipeline := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		shouldContinue := m.runBeforeMiddleware(w, r, g)
+		// Something happend in the global or group middleware and we don't want to continue
+		// This is under the assumption that the middleware handled everything.
+		if !shouldContinue {
+			return
+		}
+		// Call registered handler
+		hf(w, r)
+	})
+	wrapMiddleware(pipeline, m.middleware).ServeHTTP(w, r)
 }
 
-// Runs before middleware.
-func (m *Milo) runBeforeMiddleware(w http.ResponseWriter, r *http.Request) bool {
+// Runs before middleware, the global stack first then each group from the outermost in.
+func (m *Milo) runBeforeMiddleware(w http.ResponseWriter, r *http.Request, g *Group) bool {
 	// Running before middleware
 	for _, mdw := range m.beforeMiddleware {
 		if resp := mdw(w, r); !resp {
 			return resp
 		}
 	}
+	for _, group := range g.chain() {
+		for _, mdw := range group.beforeMiddleware {
+			if resp := mdw(w, r); !resp {
+				return resp
+			}
+		}
+	}
 	return true
 }
 
-// Runs after middleware
-func (m *Milo) runAfterMiddlware(w http.ResponseWriter, r *http.Request) {
+// Runs after middleware, each group from the innermost out then the global stack.
+func (m *Milo) runAfterMiddlware(w http.ResponseWriter, r *http.Request, g *Group) {
 	// Run through after middleware
+	for group := g; group != nil; group = group.parent {
+		for _, mdw := range group.afterMiddleware {
+			mdw(w, r)
+		}
+	}
 	for _, mdw := range m.afterMiddleware {
 		mdw(w, r)
 	}
 }
 
-// Get the connection string from the config object.
-func (m *Milo) getConnectionString() string {
-	return fmt.Sprintf("%s:%d", m.bind, m.port)
+// The full request pipeline for the application: routing, before & after middleware, panic recovery and
+// not found handling.  Mount it in another server or drive it with httptest.
+func (m *Milo) Handler() http.Handler {
+	return http.HandlerFunc(m.ServeHTTP)
 }
 
-// ServeHTTP as passed into the notfoundhandler.
+// ServeHTTP implementation, so the milo app is an http.Handler.
 func (m *Milo) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	defer handleError(m, w, r)
+	r, done := m.canonicalizePath(w, m.overrideMethod(r))
+	if done {
+		return
+	}
+	m.router.ServeHTTP(w, r)
+}
+
+// Handles requests which don't match any route, passed into the router as the not found handler.
+func (m *Milo) notFound(w http.ResponseWriter, r *http.Request) {
 	m.logger.Log("404 - Route not found.  " + r.RequestURI)
 	if m.notFoundHandler == nil {
-		w.WriteHeader(http.StatusNotFound)
-		w.Write([]byte("404 - MILO: Route not found."))
+		m.renderStatus(w, r, http.StatusNotFound, "MILO: Route not found")
 	} else {
 		m.notFoundHandler(w, r)
 	}
@@ -232,18 +343,49 @@ func (m *Milo) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 // Internal error handler for the multiple places that could cause a crashing error.
 func handleError(m *Milo, w http.ResponseWriter, r *http.Request) {
 	if err := recover(); err != nil {
-		m.logger.LogInterfaces("milo.Route", r.URL.RequestURI(), err, mux.Vars(r))
-		m.logger.LogStackTrace()
+		if pe, ok := err.(*ParamError); ok {
+			m.logger.Log("400 - " + pe.Error() + ".  " + r.RequestURI)
+			m.renderStatus(w, r, http.StatusBadRequest, "Bad Request, "+pe.Error())
+			return
+		}
+		if _, logged := err.(*loggedPanic); !logged {
+			m.logger.LogInterfaces("milo.Route", r.URL.RequestURI(), err, mux.Vars(r))
+			m.logger.LogStackTrace()
+		}
 
+		if hijacked(w) {
+			return
+		}
 		if m.defaultErrorHandler != nil {
 			m.defaultErrorHandler(w, r)
 		} else {
-			http.Error(w, "500 - Internal Server Error.", http.StatusInternalServerError)
+			m.renderStatus(w, r, http.StatusInternalServerError, "Internal Server Error")
 		}
 	}
 }
 
-// Stringer implementation
+// Render a status response, as json for api requests or through the error template registered for the status,
+// falls back to a plain text error through the registered renderer.
+func (m *Milo) renderStatus(w http.ResponseWriter, r *http.Request, code int, message string) {
+	if wantsJSON(r) {
+		writeJSONError(w, code, message)
+		return
+	}
+	if m.renderErrorTemplate(w, r, code, message) {
+		return
+	}
+	text := fmt.Sprintf("%d - %s.", code, message)
+	if m.renderer != nil {
+		m.renderer.RenderError(w, r, code, text)
+	} else {
+		http.Error(w, text, code)
+	}
+}
+
+// Stringer implementation, includes the bound address once listening.
 func (m *Milo) String() string {
+	if addr := m.Addr(); addr != nil {
+		return fmt.Sprintf("Bind: %s Port: %d Port Increment: %t Addr: %s", m.bind, m.port, m.portIncrement, addr)
+	}
 	return fmt.Sprintf("Bind: %s Port: %d Port Increment: %t", m.bind, m.port, m.portIncrement)
 }
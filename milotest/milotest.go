@@ -0,0 +1,377 @@
+// Package milotest provides helpers for driving a milo application in memory from tests.
+package milotest
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/kendellfab/milo"
+)
+
+type recorderKey struct{}
+
+// A template render captured while serving a request.
+type RenderedTemplate struct {
+	Code      int
+	Templates []string
+	Data      map[string]interface{}
+}
+
+// Builds requests for the test client.
+type RequestBuilder struct {
+	method  string
+	target  string
+	header  http.Header
+	form    url.Values
+	body    io.Reader
+	cookies []*http.Cookie
+}
+
+// Create a new request builder for the method and target path.
+func NewRequest(method, target string) *RequestBuilder {
+	return &RequestBuilder{method: method, target: target, header: make(http.Header), form: make(url.Values)}
+}
+
+// Set a header on the request.
+func (b *RequestBuilder) Header(key, value string) *RequestBuilder {
+	b.header.Set(key, value)
+	return b
+}
+
+// Add a form value, the request is sent url encoded.
+func (b *RequestBuilder) Form(key, value string) *RequestBuilder {
+	b.form.Add(key, value)
+	return b
+}
+
+// Send the value as a json body.
+func (b *RequestBuilder) JSON(v interface{}) *RequestBuilder {
+	data, err := json.Marshal(v)
+	if err != nil {
+		panic(err)
+	}
+	b.body = bytes.NewReader(data)
+	b.header.Set("Content-Type", "application/json")
+	return b
+}
+
+// Send a raw body.
+func (b *RequestBuilder) Body(body io.Reader) *RequestBuilder {
+	b.body = body
+	return b
+}
+
+// Add a cookie to the request.
+func (b *RequestBuilder) Cookie(c *http.Cookie) *RequestBuilder {
+	b.cookies = append(b.cookies, c)
+	return b
+}
+
+// Build the http request.
+func (b *RequestBuilder) Build() *http.Request {
+	body := b.body
+	if body == nil && len(b.form) > 0 {
+		body = strings.NewReader(b.form.Encode())
+		if b.header.Get("Content-Type") == "" {
+			b.header.Set("Content-Type", "application/x-www-form-urlencoded")
+		}
+	}
+	req := httptest.NewRequest(b.method, b.target, body)
+	for k, v := range b.header {
+		req.Header[k] = v
+	}
+	for _, c := range b.cookies {
+		req.AddCookie(c)
+	}
+	return req
+}
+
+// Drives a handler in memory, keeping cookies between requests like a browser would.
+type Client struct {
+	t       testing.TB
+	handler http.Handler
+	flashes *milo.FlashBase
+	auth    *milo.AuthBase
+	cookies map[string]*http.Cookie
+	hooked  map[*milo.Renderer]bool
+	mu      sync.Mutex
+}
+
+// Create a new test client for the handler, usually a *milo.Milo.
+func NewClient(t testing.TB, h http.Handler) *Client {
+	return &Client{t: t, handler: h, cookies: make(map[string]*http.Cookie), hooked: make(map[*milo.Renderer]bool)}
+}
+
+// Use the flash base to read flashes out of the cookie jar.  Rendered templates are recorded as well.
+func (c *Client) WithFlashBase(fb *milo.FlashBase) *Client {
+	c.flashes = fb
+	return c.WithRenderer(fb.Renderer)
+}
+
+// Use the auth base for logging in and reading the logged in id.  Flashes & templates are recorded as well.
+func (c *Client) WithAuthBase(ab *milo.AuthBase) *Client {
+	c.auth = ab
+	return c.WithFlashBase(ab.FlashBase)
+}
+
+// Record the templates rendered through the renderer so responses can assert on them.
+func (c *Client) WithRenderer(rend *milo.Renderer) *Client {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.hooked[rend] {
+		return c
+	}
+	c.hooked[rend] = true
+	rend.RegisterRenderHook(func(r *http.Request, code int, data map[string]interface{}, tpls ...string) {
+		if resp, ok := r.Context().Value(recorderKey{}).(*Response); ok {
+			resp.record(RenderedTemplate{Code: code, Templates: tpls, Data: data})
+		}
+	})
+	return c
+}
+
+// Send the built request through the handler.
+func (c *Client) Send(b *RequestBuilder) *Response {
+	return c.Do(b.Build())
+}
+
+// Send the request through the handler, the cookie jar is attached and updated from the response.
+func (c *Client) Do(req *http.Request) *Response {
+	c.t.Helper()
+	resp := &Response{ResponseRecorder: httptest.NewRecorder(), t: c.t, client: c}
+	req = c.withJar(req)
+	req = req.WithContext(contextWithResponse(req, resp))
+	c.handler.ServeHTTP(resp.ResponseRecorder, req)
+	c.storeCookies(resp.Result().Cookies())
+	return resp
+}
+
+// Send a get request.
+func (c *Client) Get(target string) *Response {
+	c.t.Helper()
+	return c.Send(NewRequest(http.MethodGet, target))
+}
+
+// Send a url encoded form post.
+func (c *Client) PostForm(target string, form url.Values) *Response {
+	c.t.Helper()
+	b := NewRequest(http.MethodPost, target)
+	for k, vals := range form {
+		for _, v := range vals {
+			b.Form(k, v)
+		}
+	}
+	return c.Send(b)
+}
+
+// Log the client in as the user id through the auth base session.
+func (c *Client) LoginAs(id string) {
+	c.t.Helper()
+	if c.auth == nil {
+		c.t.Fatal("milotest: LoginAs requires WithAuthBase")
+	}
+	rec := httptest.NewRecorder()
+	if err := c.auth.DoLogin(rec, c.withJar(httptest.NewRequest(http.MethodGet, "/", nil)), id); err != nil {
+		c.t.Fatalf("milotest: login failed: %v", err)
+	}
+	c.storeCookies(rec.Result().Cookies())
+}
+
+// Log the client out of the auth base session.
+func (c *Client) Logout() {
+	c.t.Helper()
+	if c.auth == nil {
+		c.t.Fatal("milotest: Logout requires WithAuthBase")
+	}
+	rec := httptest.NewRecorder()
+	if err := c.auth.DoLogout(rec, c.withJar(httptest.NewRequest(http.MethodGet, "/", nil))); err != nil {
+		c.t.Fatalf("milotest: logout failed: %v", err)
+	}
+	c.storeCookies(rec.Result().Cookies())
+}
+
+// The user id logged in through the auth base session.
+func (c *Client) UserID() (string, bool) {
+	c.t.Helper()
+	if c.auth == nil {
+		c.t.Fatal("milotest: UserID requires WithAuthBase")
+	}
+	return c.auth.SessionID(c.withJar(httptest.NewRequest(http.MethodGet, "/", nil)))
+}
+
+// The pending error and success flashes in the cookie jar, they are not cleared.
+func (c *Client) Flashes() ([]interface{}, []interface{}) {
+	c.t.Helper()
+	if c.flashes == nil {
+		c.t.Fatal("milotest: Flashes requires WithFlashBase")
+	}
+	return c.flashes.PeekFlashes(c.withJar(httptest.NewRequest(http.MethodGet, "/", nil)))
+}
+
+// The cookies currently held by the client.
+func (c *Client) Cookies() []*http.Cookie {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	list := make([]*http.Cookie, 0, len(c.cookies))
+	for _, cookie := range c.cookies {
+		list = append(list, cookie)
+	}
+	return list
+}
+
+// Attach the jar cookies to the request.
+func (c *Client) withJar(req *http.Request) *http.Request {
+	for _, cookie := range c.Cookies() {
+		if _, err := req.Cookie(cookie.Name); err != nil {
+			req.AddCookie(&http.Cookie{Name: cookie.Name, Value: cookie.Value})
+		}
+	}
+	return req
+}
+
+// Update the jar from set cookie headers, expired cookies are removed.
+func (c *Client) storeCookies(cookies []*http.Cookie) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, cookie := range cookies {
+		if cookie.MaxAge < 0 || (!cookie.Expires.IsZero() && cookie.Expires.Before(time.Now())) {
+			delete(c.cookies, cookie.Name)
+			continue
+		}
+		c.cookies[cookie.Name] = cookie
+	}
+}
+
+// The recorded response with assertion helpers.
+type Response struct {
+	*httptest.ResponseRecorder
+	t         testing.TB
+	client    *Client
+	templates []RenderedTemplate
+	mu        sync.Mutex
+}
+
+// The templates rendered while serving the request.
+func (r *Response) Templates() []RenderedTemplate {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]RenderedTemplate(nil), r.templates...)
+}
+
+// Assert the response status code.
+func (r *Response) AssertStatus(code int) *Response {
+	r.t.Helper()
+	if r.Code != code {
+		r.t.Errorf("milotest: expected status %d, got %d", code, r.Code)
+	}
+	return r
+}
+
+// Assert the response body contains the string.
+func (r *Response) AssertBodyContains(s string) *Response {
+	r.t.Helper()
+	if !strings.Contains(r.Body.String(), s) {
+		r.t.Errorf("milotest: expected body to contain %q, got %q", s, r.Body.String())
+	}
+	return r
+}
+
+// Assert the response is a redirect to the location.
+func (r *Response) AssertRedirect(location string) *Response {
+	r.t.Helper()
+	if r.Code < 300 || r.Code >= 400 {
+		r.t.Errorf("milotest: expected redirect, got status %d", r.Code)
+	}
+	if got := r.Header().Get("Location"); got != location {
+		r.t.Errorf("milotest: expected redirect to %q, got %q", location, got)
+	}
+	return r
+}
+
+// Assert the template was rendered while serving the request.
+func (r *Response) AssertTemplate(tpl string) *Response {
+	r.t.Helper()
+	if _, ok := r.findTemplate(tpl); !ok {
+		r.t.Errorf("milotest: expected template %q to be rendered, got %v", tpl, r.Templates())
+	}
+	return r
+}
+
+// Assert the template was rendered with the data key set to the value.
+func (r *Response) AssertTemplateData(tpl, key string, value interface{}) *Response {
+	r.t.Helper()
+	rendered, ok := r.findTemplate(tpl)
+	if !ok {
+		r.t.Errorf("milotest: expected template %q to be rendered, got %v", tpl, r.Templates())
+		return r
+	}
+	if got, ok := rendered.Data[key]; !ok || !equal(got, value) {
+		r.t.Errorf("milotest: expected template %q data %q to be %v, got %v", tpl, key, value, got)
+	}
+	return r
+}
+
+// Assert an error flash with the message is pending in the client cookie jar.
+func (r *Response) AssertErrorFlash(message string) *Response {
+	r.t.Helper()
+	errs, _ := r.client.Flashes()
+	if !containsFlash(errs, message) {
+		r.t.Errorf("milotest: expected error flash %q, got %v", message, errs)
+	}
+	return r
+}
+
+// Assert a success flash with the message is pending in the client cookie jar.
+func (r *Response) AssertSuccessFlash(message string) *Response {
+	r.t.Helper()
+	_, successes := r.client.Flashes()
+	if !containsFlash(successes, message) {
+		r.t.Errorf("milotest: expected success flash %q, got %v", message, successes)
+	}
+	return r
+}
+
+func (r *Response) record(rendered RenderedTemplate) {
+	r.mu.Lock()
+	r.templates = append(r.templates, rendered)
+	r.mu.Unlock()
+}
+
+func (r *Response) findTemplate(tpl string) (RenderedTemplate, bool) {
+	for _, rendered := range r.Templates() {
+		for _, name := range rendered.Templates {
+			if name == tpl {
+				return rendered, true
+			}
+		}
+	}
+	return RenderedTemplate{}, false
+}
+
+func contextWithResponse(req *http.Request, resp *Response) context.Context {
+	return context.WithValue(req.Context(), recorderKey{}, resp)
+}
+
+func containsFlash(flashes []interface{}, message string) bool {
+	for _, f := range flashes {
+		if s, ok := f.(string); ok && s == message {
+			return true
+		}
+	}
+	return false
+}
+
+func equal(a, b interface{}) bool {
+	ja, errA := json.Marshal(a)
+	jb, errB := json.Marshal(b)
+	return errA == nil && errB == nil && bytes.Equal(ja, jb)
+}
@@ -0,0 +1,79 @@
+package milotest_test
+
+import (
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/gorilla/sessions"
+	"github.com/kendellfab/milo"
+	"github.com/kendellfab/milo/milotest"
+)
+
+type userCheck struct{}
+
+func (userCheck) IsValid(id string) (bool, error) {
+	return id == "42", nil
+}
+
+func (userCheck) IsTokenValid(token string) (bool, error) {
+	return false, nil
+}
+
+func newTestApp(t *testing.T) (*milo.Milo, *milo.AuthBase) {
+	t.Helper()
+	app, err := milo.NewMilo()
+	if err != nil {
+		t.Fatal(err)
+	}
+	rend := milo.NewRenderer("testdata", false, nil)
+	app.RegisterRenderer(rend)
+	store := sessions.NewCookieStore([]byte("milotest-session-key-0123456789ab"))
+	fb := milo.NewFlashBase(rend, store)
+	ab := milo.NewAuthBase(fb, userCheck{}, "/login")
+
+	app.Route("/dashboard", []string{http.MethodGet}, ab.AuthMiddlewareCookie(func(w http.ResponseWriter, r *http.Request) {
+		id, _ := milo.IdFromContext(r.Context())
+		fb.RenderTemplates(w, r, map[string]interface{}{"user": id}, "dashboard.tpl")
+	}))
+	app.Route("/settings", []string{http.MethodPost}, ab.AuthMiddlewareCookie(func(w http.ResponseWriter, r *http.Request) {
+		fb.SetSuccessFlash(w, r, "Saved "+r.FormValue("name"))
+		fb.Redirect(w, r, "/dashboard", http.StatusSeeOther)
+	}))
+	return app, ab
+}
+
+func TestLoginAsRendersTemplate(t *testing.T) {
+	app, ab := newTestApp(t)
+	c := milotest.NewClient(t, app.Handler()).WithAuthBase(ab)
+
+	c.LoginAs("42")
+	if id, ok := c.UserID(); !ok || id != "42" {
+		t.Fatalf("expected to be logged in as 42, got %q %v", id, ok)
+	}
+	c.Get("/dashboard").
+		AssertStatus(http.StatusOK).
+		AssertTemplate("dashboard.tpl").
+		AssertTemplateData("dashboard.tpl", "user", "42").
+		AssertBodyContains("Dashboard for 42")
+
+	c.Logout()
+	if _, ok := c.UserID(); ok {
+		t.Error("expected to be logged out")
+	}
+}
+
+func TestFlashAssertions(t *testing.T) {
+	app, ab := newTestApp(t)
+	c := milotest.NewClient(t, app.Handler()).WithAuthBase(ab)
+
+	c.Get("/dashboard").
+		AssertRedirect("/login").
+		AssertErrorFlash("/dashboard requires authentication.")
+
+	c.LoginAs("42")
+	c.PostForm("/settings", url.Values{"name": {"profile"}}).
+		AssertRedirect("/dashboard").
+		AssertSuccessFlash("Saved profile")
+	c.Get("/dashboard").AssertBodyContains("Saved profile")
+}
@@ -0,0 +1 @@
+<h1>Dashboard for {{.user}}</h1>{{range .flashsuccess}}<p>{{.}}</p>{{end}}
@@ -0,0 +1,85 @@
+package milo
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gorilla/mux"
+)
+
+// Options for a mounted handler.
+type MountOptions struct {
+	Methods    []string     // Methods to match, nil matches every method.
+	KeepPrefix bool         // Pass the full path through, for handlers like pprof which expect it.
+	Middleware []Middleware // Route middleware wrapping the handler.
+}
+
+// Mount a handler tree under the prefix, such as pprof, a single page app server or another milo app.  The prefix
+// is stripped from the request path and the handler runs through the milo pipeline with the global middleware and
+// panic recovery.  The prefix matches whole path segments, "/admin" serves "/admin/users" but not "/administrator".
+func (m *Milo) Mount(prefix string, h http.Handler, opts MountOptions) *MiloRoute {
+	return m.mount(m.router, prefix, prefix, h, opts, nil)
+}
+
+// Mount a handler tree under the prefix inside the group, the group and mount prefixes are both stripped.
+func (g *Group) Mount(prefix string, h http.Handler, opts MountOptions) *MiloRoute {
+	return g.milo.mount(g.router, prefix, g.prefix+prefix, h, opts, g)
+}
+
+// Register the mount as a route for the prefix itself plus a route for everything below it, so the prefix only
+// matches on a segment boundary.
+func (m *Milo) mount(router *mux.Router, prefix, path string, h http.Handler, opts MountOptions, g *Group) *MiloRoute {
+	tpl := strings.TrimSuffix(prefix, "/")
+	if tpl == "" {
+		return m.handle(router.PathPrefix, KindMount, "/", path, opts.Methods, mountHandler(nil, h, opts).ServeHTTP, g, opts.Middleware)
+	}
+
+	var mounted http.Handler
+	mr := m.handle(router.Path, KindMount, tpl, path, opts.Methods, func(w http.ResponseWriter, r *http.Request) {
+		mounted.ServeHTTP(w, r)
+	}, g, opts.Middleware)
+	mounted = mountHandler(mr, h, opts)
+
+	subtree, _ := parseParamTypes(tpl + "/")
+	route := router.PathPrefix(subtree)
+	if mr.methods != nil {
+		route = route.Methods(mr.methods...)
+	}
+	mr.subtree = route.Handler(mr.route.GetHandler())
+	return mr
+}
+
+// Wrap the mounted handler so it sees the path below the prefix the request matched.
+func mountHandler(mr *MiloRoute, h http.Handler, opts MountOptions) http.Handler {
+	if opts.KeepPrefix || mr == nil {
+		return h
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		prefix, err := mr.route.URLPath(varPairs(r)...)
+		if err != nil {
+			http.NotFound(w, r)
+			return
+		}
+		path, ok := strings.CutPrefix(r.URL.Path, prefix.Path)
+		if !ok || (path != "" && !strings.HasPrefix(path, "/")) {
+			http.NotFound(w, r)
+			return
+		}
+		sr := r.Clone(r.Context())
+		u := *r.URL
+		u.Path = "/" + strings.TrimLeft(path, "/")
+		u.RawPath = ""
+		sr.URL = &u
+		sr.RequestURI = u.RequestURI()
+		h.ServeHTTP(w, sr)
+	})
+}
+
+// The route variables as key & value pairs for building the matched prefix.
+func varPairs(r *http.Request) []string {
+	pairs := make([]string, 0)
+	for k, v := range mux.Vars(r) {
+		pairs = append(pairs, k, v)
+	}
+	return pairs
+}
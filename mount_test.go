@@ -0,0 +1,72 @@
+package milo_test
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/kendellfab/milo"
+	"github.com/kendellfab/milo/milotest"
+)
+
+func TestMountStripsPrefixOnSegmentBoundary(t *testing.T) {
+	app, err := milo.NewMilo()
+	if err != nil {
+		t.Fatal(err)
+	}
+	app.Mount("/admin", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("mounted " + r.URL.Path))
+	}), milo.MountOptions{})
+	app.Route("/administrator", []string{http.MethodGet}, func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("administrator"))
+	})
+
+	c := milotest.NewClient(t, app.Handler())
+	c.Get("/admin").AssertStatus(http.StatusOK).AssertBodyContains("mounted /")
+	c.Get("/admin/").AssertStatus(http.StatusOK).AssertBodyContains("mounted /")
+	c.Get("/admin/users/3").AssertStatus(http.StatusOK).AssertBodyContains("mounted /users/3")
+	c.Get("/administrator").AssertStatus(http.StatusOK).AssertBodyContains("administrator")
+	c.Get("/adminx").AssertStatus(http.StatusNotFound)
+}
+
+func TestMountRunsMiddlewareAndRecovers(t *testing.T) {
+	app, err := milo.NewMilo()
+	if err != nil {
+		t.Fatal(err)
+	}
+	app.RegisterBefore(func(w http.ResponseWriter, r *http.Request) bool {
+		w.Header().Set("X-Before", "ran")
+		return true
+	})
+	app.Group("/api").Mount("/boom/", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic("mounted handler failed")
+	}), milo.MountOptions{})
+
+	c := milotest.NewClient(t, app.Handler())
+	resp := c.Get("/api/boom/now").AssertStatus(http.StatusInternalServerError)
+	if resp.Header().Get("X-Before") != "ran" {
+		t.Error("expected before middleware to run for the mount")
+	}
+
+	routes := app.Routes()
+	if len(routes) != 1 || routes[0].Kind != milo.KindMount || routes[0].Path != "/api/boom/" {
+		t.Errorf("expected the mount in the routes, got %+v", routes)
+	}
+}
+
+func TestMountMethodNotAllowedBelowPrefix(t *testing.T) {
+	app, err := milo.NewMilo()
+	if err != nil {
+		t.Fatal(err)
+	}
+	app.Mount("/admin", http.HandlerFunc(okHandler), milo.MountOptions{Methods: []string{http.MethodGet}})
+
+	c := milotest.NewClient(t, app.Handler())
+	c.Get("/admin/x").AssertStatus(http.StatusOK)
+	for _, path := range []string{"/admin", "/admin/x"} {
+		resp := c.Send(milotest.NewRequest(http.MethodPost, path)).AssertStatus(http.StatusMethodNotAllowed)
+		if got := resp.Header().Get("Allow"); got != "GET, HEAD, OPTIONS" {
+			t.Errorf("expected Allow of GET, HEAD, OPTIONS for %s, got %q", path, got)
+		}
+	}
+	c.Send(milotest.NewRequest(http.MethodOptions, "/admin/x")).AssertStatus(http.StatusNoContent)
+}
@@ -4,12 +4,15 @@ import (
 	"bytes"
 	"errors"
 	html "html/template"
+	"io/fs"
+	"path"
 	"path/filepath"
 	"text/template"
 )
 
 type MsgRender struct {
 	tplDir   string
+	fsys     fs.FS
 	tplFuncs map[string]interface{}
 }
 
@@ -19,6 +22,13 @@ func NewMsgRender(tplDir string) *MsgRender {
 	return m
 }
 
+// Create a message renderer reading templates from the file system, such as an embed.FS.
+func NewMsgRenderFS(fsys fs.FS) *MsgRender {
+	m := NewMsgRender("")
+	m.fsys = fsys
+	return m
+}
+
 func (m *MsgRender) RegisterTemplateFunc(key string, fn interface{}) {
 	m.tplFuncs[key] = fn
 }
@@ -27,12 +37,15 @@ func (m *MsgRender) Render(data interface{}, tpls ...string) (string, error) {
 	if len(tpls) < 1 {
 		return "", errors.New("Template identifiers required to render.")
 	}
-	list := make([]string, 0)
-	for _, elem := range tpls {
-		list = append(list, filepath.Join(m.tplDir, elem))
+	var tpl *template.Template
+	var tplErr error
+	if m.fsys != nil {
+		tpl, tplErr = template.New(path.Base(tpls[0])).ParseFS(m.fsys, tpls...)
+	} else {
+		tpl, tplErr = template.New(filepath.Base(tpls[0])).ParseFiles(m.tplFiles(tpls)...)
 	}
 
-	if tpl, tplErr := template.New(filepath.Base(tpls[0])).ParseFiles(list...); tplErr != nil {
+	if tplErr != nil {
 		return "", tplErr
 	} else {
 		output := bytes.NewBufferString("")
@@ -47,12 +60,15 @@ func (m *MsgRender) RenderHtml(data interface{}, tpls ...string) (string, error)
 	if len(tpls) < 1 {
 		return "", errors.New("Template identifiers required to render.")
 	}
-	list := make([]string, 0)
-	for _, elem := range tpls {
-		list = append(list, filepath.Join(m.tplDir, elem))
+	var tpl *html.Template
+	var tplErr error
+	if m.fsys != nil {
+		tpl, tplErr = html.New(path.Base(tpls[0])).ParseFS(m.fsys, tpls...)
+	} else {
+		tpl, tplErr = html.New(filepath.Base(tpls[0])).ParseFiles(m.tplFiles(tpls)...)
 	}
 
-	if tpl, tplErr := html.New(filepath.Base(tpls[0])).ParseFiles(list...); tplErr != nil {
+	if tplErr != nil {
 		return "", tplErr
 	} else {
 		output := bytes.NewBufferString("")
@@ -62,3 +78,12 @@ func (m *MsgRender) RenderHtml(data interface{}, tpls ...string) (string, error)
 		return output.String(), nil
 	}
 }
+
+// The template paths joined to the template directory.
+func (m *MsgRender) tplFiles(tpls []string) []string {
+	list := make([]string, 0)
+	for _, elem := range tpls {
+		list = append(list, filepath.Join(m.tplDir, elem))
+	}
+	return list
+}
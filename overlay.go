@@ -0,0 +1,54 @@
+package milo
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"sort"
+)
+
+// Reads from a disk directory first and falls back to the base file system.
+type overlayFS struct {
+	disk fs.FS
+	base fs.FS
+}
+
+// Overlay a disk directory on a file system, usually an embed.FS, so templates and assets can be edited live during
+// development while anything missing on disk still comes from the embedded copy.  Turn template caching off to see
+// the edits.
+func OverlayFS(dir string, base fs.FS) fs.FS {
+	return &overlayFS{disk: os.DirFS(dir), base: base}
+}
+
+func (o *overlayFS) Open(name string) (fs.File, error) {
+	f, err := o.disk.Open(name)
+	if err == nil || !errors.Is(err, fs.ErrNotExist) {
+		return f, err
+	}
+	return o.base.Open(name)
+}
+
+// Merge the directory listings, disk entries win.
+func (o *overlayFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	diskEntries, diskErr := fs.ReadDir(o.disk, name)
+	baseEntries, baseErr := fs.ReadDir(o.base, name)
+	if diskErr != nil && baseErr != nil {
+		return nil, diskErr
+	}
+
+	seen := make(map[string]bool)
+	entries := make([]fs.DirEntry, 0, len(diskEntries)+len(baseEntries))
+	for _, entry := range diskEntries {
+		seen[entry.Name()] = true
+		entries = append(entries, entry)
+	}
+	for _, entry := range baseEntries {
+		if !seen[entry.Name()] {
+			entries = append(entries, entry)
+		}
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Name() < entries[j].Name()
+	})
+	return entries, nil
+}
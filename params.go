@@ -0,0 +1,209 @@
+package milo
+
+import (
+	"fmt"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/gorilla/mux"
+)
+
+const (
+	ParamTypeInt   = "int"
+	ParamTypeUint  = "uint"
+	ParamTypeUUID  = "uuid"
+	ParamTypeAlpha = "alpha"
+)
+
+const uuidRegexp = `[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}`
+
+var (
+	uuidPattern  = regexp.MustCompile(`^` + uuidRegexp + `$`)
+	alphaPattern = regexp.MustCompile(`^[a-zA-Z]+$`)
+
+	// The gorilla mux patterns for the param types, so a value of the wrong type doesn't match the route.
+	paramTypePatterns = map[string]string{
+		ParamTypeInt:   `-?[0-9]+`,
+		ParamTypeUint:  `[0-9]+`,
+		ParamTypeUUID:  uuidRegexp,
+		ParamTypeAlpha: `[a-zA-Z]+`,
+	}
+)
+
+// A path or query parameter which couldn't be parsed.  The param helpers panic with it to abort the request,
+// which milo turns into a 400 rendered through the registered renderer.
+type ParamError struct {
+	Name  string
+	Value string
+	Type  string
+	Err   error
+}
+
+func (pe *ParamError) Error() string {
+	return fmt.Sprintf("invalid %s %q, expected %s", pe.Name, pe.Value, pe.Type)
+}
+
+func (pe *ParamError) Unwrap() error {
+	return pe.Err
+}
+
+// Get a path parameter as a string, aborts with a 400 when it is missing.
+func Param(r *http.Request, name string) string {
+	value, ok := mux.Vars(r)[name]
+	if !ok {
+		panic(&ParamError{Name: name, Type: "a value"})
+	}
+	return value
+}
+
+// Get a path parameter as an int, aborts with a 400 when it isn't one.
+func ParamInt(r *http.Request, name string) int {
+	value := Param(r, name)
+	i, err := strconv.Atoi(value)
+	if err != nil {
+		panic(&ParamError{Name: name, Value: value, Type: ParamTypeInt, Err: err})
+	}
+	return i
+}
+
+// Get a path parameter as an int64, aborts with a 400 when it isn't one.
+func ParamInt64(r *http.Request, name string) int64 {
+	value := Param(r, name)
+	i, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		panic(&ParamError{Name: name, Value: value, Type: ParamTypeInt, Err: err})
+	}
+	return i
+}
+
+// Get a path parameter as a lowercased uuid string, aborts with a 400 when it isn't one.
+func ParamUUID(r *http.Request, name string) string {
+	value := Param(r, name)
+	if !uuidPattern.MatchString(value) {
+		panic(&ParamError{Name: name, Value: value, Type: ParamTypeUUID})
+	}
+	return strings.ToLower(value)
+}
+
+// Get a query parameter, or the default when it is missing.
+func QueryString(r *http.Request, key string, def string) string {
+	values, ok := r.URL.Query()[key]
+	if !ok || len(values) == 0 {
+		return def
+	}
+	return values[0]
+}
+
+// Get a query parameter as an int, or the default when it is missing.  Aborts with a 400 when it isn't an int.
+func QueryInt(r *http.Request, key string, def int) int {
+	value := QueryString(r, key, "")
+	if value == "" {
+		return def
+	}
+	i, err := strconv.Atoi(value)
+	if err != nil {
+		panic(&ParamError{Name: key, Value: value, Type: ParamTypeInt, Err: err})
+	}
+	return i
+}
+
+// Get a query parameter as a bool, or the default when it is missing.  Aborts with a 400 when it isn't a bool.
+func QueryBool(r *http.Request, key string, def bool) bool {
+	value := QueryString(r, key, "")
+	if value == "" {
+		return def
+	}
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		panic(&ParamError{Name: key, Value: value, Type: "bool", Err: err})
+	}
+	return b
+}
+
+// Pull the typed variables like {id:int} out of a route template, they are replaced with gorilla mux pattern
+// variables like {id:-?[0-9]+} so other values fall through to later routes.  The types are returned so the values
+// can be checked before the handler runs.
+func parseParamTypes(tpl string) (string, map[string]string) {
+	var out strings.Builder
+	types := make(map[string]string)
+	for i := 0; i < len(tpl); i++ {
+		if tpl[i] != '{' {
+			out.WriteByte(tpl[i])
+			continue
+		}
+		end, depth := i, 0
+		for ; end < len(tpl); end++ {
+			if tpl[end] == '{' {
+				depth++
+			} else if tpl[end] == '}' {
+				depth--
+				if depth == 0 {
+					break
+				}
+			}
+		}
+		if end == len(tpl) {
+			out.WriteString(tpl[i:])
+			break
+		}
+		variable := tpl[i+1 : end]
+		if name, typ, ok := strings.Cut(variable, ":"); ok && knownParamType(typ) {
+			types[name] = typ
+			out.WriteString("{" + name + ":" + paramTypePatterns[typ] + "}")
+		} else {
+			out.WriteString(tpl[i : end+1])
+		}
+		i = end
+	}
+	return out.String(), types
+}
+
+func knownParamType(typ string) bool {
+	_, ok := paramTypePatterns[typ]
+	return ok
+}
+
+// Check a value against a declared param type.
+func validParam(typ, value string) bool {
+	switch typ {
+	case ParamTypeInt:
+		_, err := strconv.ParseInt(value, 10, 64)
+		return err == nil
+	case ParamTypeUint:
+		_, err := strconv.ParseUint(value, 10, 64)
+		return err == nil
+	case ParamTypeUUID:
+		return uuidPattern.MatchString(value)
+	case ParamTypeAlpha:
+		return alphaPattern.MatchString(value)
+	}
+	return true
+}
+
+// Wraps the handler so the typed route variables are checked before it runs.  The route patterns already match the
+// type, this catches numbers which overflow an int64 or uint64.
+func (m *Milo) paramHandler(mr *MiloRoute, g *Group, hf http.HandlerFunc) http.HandlerFunc {
+	types := make(map[string]string)
+	for _, group := range g.chain() {
+		for name, typ := range group.paramTypes {
+			types[name] = typ
+		}
+	}
+	for name, typ := range mr.paramTypes {
+		types[name] = typ
+	}
+	if len(types) == 0 {
+		return hf
+	}
+	return func(w http.ResponseWriter, r *http.Request) {
+		vars := mux.Vars(r)
+		for name, typ := range types {
+			if value, ok := vars[name]; ok && !validParam(typ, value) {
+				panic(&ParamError{Name: name, Value: value, Type: typ})
+			}
+		}
+		hf(w, r)
+	}
+}
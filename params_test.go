@@ -0,0 +1,69 @@
+package milo_test
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/kendellfab/milo"
+	"github.com/kendellfab/milo/milotest"
+)
+
+func TestTypedParams(t *testing.T) {
+	app, err := milo.NewMilo()
+	if err != nil {
+		t.Fatal(err)
+	}
+	reached := false
+	app.Route("/users/{id:int}", []string{http.MethodGet}, func(w http.ResponseWriter, r *http.Request) {
+		reached = true
+		fmt.Fprint(w, milo.ParamInt(r, "id"))
+	})
+	app.Route("/users/new", []string{http.MethodGet}, func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("new user"))
+	})
+	app.Route("/pages/{n:uint}", []string{http.MethodGet}, okHandler)
+	app.Route("/docs/{key:uuid}", []string{http.MethodGet}, func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(milo.ParamUUID(r, "key")))
+	})
+	app.Route("/tags/{tag:alpha}", []string{http.MethodGet}, okHandler)
+
+	c := milotest.NewClient(t, app.Handler())
+	c.Get("/users/42").AssertStatus(http.StatusOK).AssertBodyContains("42")
+	c.Get("/users/-3").AssertStatus(http.StatusOK).AssertBodyContains("-3")
+	reached = false
+	c.Get("/users/new").AssertStatus(http.StatusOK).AssertBodyContains("new user")
+	c.Get("/users/abc").AssertStatus(http.StatusNotFound)
+	if reached {
+		t.Error("expected the typed route not to match other values")
+	}
+	c.Get("/users/99999999999999999999").AssertStatus(http.StatusBadRequest)
+	c.Get("/pages/2").AssertStatus(http.StatusOK)
+	c.Get("/pages/-2").AssertStatus(http.StatusNotFound)
+	c.Get("/docs/0F8FAD5B-D9CB-469F-A165-70867728950E").AssertStatus(http.StatusOK).
+		AssertBodyContains("0f8fad5b-d9cb-469f-a165-70867728950e")
+	c.Get("/docs/not-a-uuid").AssertStatus(http.StatusNotFound)
+	c.Get("/tags/golang").AssertStatus(http.StatusOK)
+	c.Get("/tags/go1").AssertStatus(http.StatusNotFound)
+}
+
+func TestParamHelpersAbortWithBadRequest(t *testing.T) {
+	app, err := milo.NewMilo()
+	if err != nil {
+		t.Fatal(err)
+	}
+	app.Route("/items/{id}", []string{http.MethodGet}, func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, milo.ParamInt(r, "id"))
+	})
+	app.Route("/search", []string{http.MethodGet}, func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, milo.QueryInt(r, "page", 1), milo.QueryBool(r, "all", false))
+	})
+
+	c := milotest.NewClient(t, app.Handler())
+	c.Get("/items/7").AssertStatus(http.StatusOK).AssertBodyContains("7")
+	c.Get("/items/seven").AssertStatus(http.StatusBadRequest).AssertBodyContains(`invalid id "seven", expected int`)
+	c.Get("/search").AssertStatus(http.StatusOK).AssertBodyContains("1 false")
+	c.Get("/search?page=3&all=true").AssertStatus(http.StatusOK).AssertBodyContains("3 true")
+	c.Get("/search?page=three").AssertStatus(http.StatusBadRequest)
+	c.Get("/search?all=maybe").AssertStatus(http.StatusBadRequest)
+}
@@ -3,8 +3,13 @@ package milo
 import (
 	"bytes"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"html/template"
+	"io/fs"
 	"net/http"
+	"os"
+	"path"
 	"path/filepath"
 	"strings"
 	"sync"
@@ -15,13 +20,19 @@ type Configer interface {
 	GetConfig(key string) interface{}
 }
 
+// A hook which is called with the templates and data each time templates are rendered.
+type RenderHook func(r *http.Request, code int, data map[string]interface{}, tpls ...string)
+
 // Default milo renderer that can cache templates, sets a base template directory.
 type Renderer struct {
 	templateCache map[string]*template.Template
 	tplDir        string
+	fsys          fs.FS
 	tplFuncs      map[string]interface{}
 	cacheTpls     bool
 	configer      Configer
+	renderHooks   []RenderHook
+	urlBuilder    URLBuilder
 	sync.RWMutex
 }
 
@@ -33,6 +44,15 @@ func NewRenderer(tplDir string, cache bool, configer Configer) *Renderer {
 	r.tplFuncs["partial"] = r.Partial
 	r.tplFuncs["title"] = Title
 	r.tplFuncs["gravatar"] = Gravatar
+	r.tplFuncs["url"] = r.URL
+	return r
+}
+
+// Create a new default milo renderer reading templates from the file system, such as an embed.FS.  Use
+// OverlayFS to edit the templates on disk during development.
+func NewRendererFS(fsys fs.FS, cache bool, configer Configer) *Renderer {
+	r := NewRenderer("", cache, configer)
+	r.fsys = fsys
 	return r
 }
 
@@ -48,6 +68,19 @@ func (mr *Renderer) RenderTemplatesCode(w http.ResponseWriter, r *http.Request,
 		w.Write([]byte("Error: Template required!"))
 		return
 	}
+	doc, err := mr.renderBytes(r, code, data, tpls...)
+	if err != nil {
+		w.WriteHeader(500)
+		w.Write([]byte(err.Error()))
+		return
+	}
+	w.WriteHeader(code)
+	w.Write(doc)
+}
+
+// Execute the templates into a buffer, running the render hooks, so a failed render can be handled before
+// anything is written.
+func (mr *Renderer) renderBytes(r *http.Request, code int, data map[string]interface{}, tpls ...string) ([]byte, error) {
 	defaults := make(map[string]interface{})
 	if mr.configer != nil {
 		defaults["config"] = mr.configer
@@ -55,26 +88,56 @@ func (mr *Renderer) RenderTemplatesCode(w http.ResponseWriter, r *http.Request,
 	for k, v := range data {
 		defaults[k] = v
 	}
+	mr.RLock()
+	hooks := mr.renderHooks
+	mr.RUnlock()
+	for _, hook := range hooks {
+		hook(r, code, defaults, tpls...)
+	}
 
 	list := make([]string, 0)
 	for _, elem := range tpls {
-		list = append(list, filepath.Join(mr.tplDir, elem))
+		list = append(list, mr.tplPath(elem))
 	}
 
-	if tpl, loadErr := mr.acquireTemplate(strings.Join(tpls, ""), list...); loadErr != nil {
-		w.WriteHeader(500)
-		w.Write([]byte(loadErr.Error()))
-	} else {
-		var doc bytes.Buffer
-		err := tpl.Execute(&doc, defaults)
-		if err == nil {
-			w.WriteHeader(code)
-			w.Write(doc.Bytes())
-		} else {
-			w.WriteHeader(500)
-			w.Write([]byte(err.Error()))
-		}
+	tpl, err := mr.acquireTemplate(strings.Join(tpls, ""), list...)
+	if err != nil {
+		return nil, err
+	}
+	var doc bytes.Buffer
+	if err := tpl.Execute(&doc, defaults); err != nil {
+		return nil, err
+	}
+	return doc.Bytes(), nil
+}
+
+// Parse a template set ahead of time so template errors show up at startup, the set is cached when caching is on.
+func (mr *Renderer) Precompile(tpls ...string) error {
+	if len(tpls) < 1 {
+		return errors.New("Error: Template required!")
+	}
+	list := make([]string, 0)
+	for _, elem := range tpls {
+		list = append(list, mr.tplPath(elem))
+	}
+	_, err := mr.acquireTemplate(strings.Join(tpls, ""), list...)
+	return err
+}
+
+// The path of a template, relative to the file system root or joined to the template directory.
+func (mr *Renderer) tplPath(name string) string {
+	if mr.fsys != nil {
+		return path.Clean(name)
 	}
+	return filepath.Join(mr.tplDir, name)
+}
+
+// The templates as a file system, for walking them.
+func (mr *Renderer) templateFS() fs.FS {
+	if mr.fsys != nil {
+		return mr.fsys
+	}
+	return os.DirFS(mr.tplDir)
 }
 
 // Unexported method to help handle template parsing.  If the cache template bool is set on the config
@@ -94,7 +157,12 @@ func (mr *Renderer) acquireTemplate(key string, tpls ...string) (*template.Templ
 		}
 	}
 
-	tpl, loadErr = template.New(filepath.Base(tpls[0])).Funcs(mr.tplFuncs).ParseFiles(tpls...)
+	tpl = template.New(filepath.Base(tpls[0])).Funcs(mr.tplFuncs)
+	if mr.fsys != nil {
+		tpl, loadErr = tpl.ParseFS(mr.fsys, tpls...)
+	} else {
+		tpl, loadErr = tpl.ParseFiles(tpls...)
+	}
 	if loadErr != nil {
 		return nil, loadErr
 	}
@@ -134,17 +202,52 @@ func (mr *Renderer) RegisterTemplateFunc(key string, fn interface{}) {
 	mr.tplFuncs[key] = fn
 }
 
+// Register a hook to observe rendered templates, useful for tests & instrumentation.
+func (mr *Renderer) RegisterRenderHook(hook RenderHook) {
+	mr.Lock()
+	mr.renderHooks = append(mr.renderHooks, hook)
+	mr.Unlock()
+}
+
+// Register the url builder for named routes, done by Milo.RegisterRenderer.
+func (mr *Renderer) RegisterURLBuilder(ub URLBuilder) {
+	mr.urlBuilder = ub
+}
+
+// Build the url for a named route, param values are formatted as strings.
+// Can be used like {{ url "post" "id" .post.ID }}
+func (mr *Renderer) URL(name string, params ...interface{}) (string, error) {
+	if mr.urlBuilder == nil {
+		return "", errors.New("milo: no url builder registered with the renderer")
+	}
+	pairs := make([]string, 0, len(params))
+	for _, p := range params {
+		pairs = append(pairs, fmt.Sprint(p))
+	}
+	return mr.urlBuilder.URL(name, pairs...)
+}
+
 // Setup an http redirect on the request.
 func (mr *Renderer) Redirect(w http.ResponseWriter, r *http.Request, url string, code int) {
 	http.Redirect(w, r, url, code)
 }
 
+// Setup an http redirect to a named route, params are the route variable key & value pairs.
+func (mr *Renderer) RedirectRoute(w http.ResponseWriter, r *http.Request, name string, code int, params ...interface{}) error {
+	url, err := mr.URL(name, params...)
+	if err != nil {
+		return err
+	}
+	mr.Redirect(w, r, url, code)
+	return nil
+}
+
 // A template function which can include a partial template.
 func (mr *Renderer) Partial(name string, payload interface{}) (template.HTML, error) {
 	var buff bytes.Buffer
-	path := filepath.Join(mr.tplDir, "partials", name)
+	file := mr.tplPath(path.Join("partials", name))
 
-	tpl, loadErr := mr.acquireTemplate(path, path)
+	tpl, loadErr := mr.acquireTemplate(file, file)
 	if loadErr != nil {
 		return "", loadErr
 	}
@@ -0,0 +1,121 @@
+package milo
+
+import (
+	"mime"
+	"net/http"
+	"strings"
+)
+
+const (
+	methodOverrideHeader = "X-HTTP-Method-Override"
+	methodOverrideField  = "_method"
+)
+
+// Lists the resources, GET path.
+type ResourceIndex interface {
+	Index(w http.ResponseWriter, r *http.Request)
+}
+
+// Shows a single resource, GET path/{id}.
+type ResourceShow interface {
+	Show(w http.ResponseWriter, r *http.Request)
+}
+
+// Shows the form for a new resource, GET path/new.
+type ResourceNew interface {
+	New(w http.ResponseWriter, r *http.Request)
+}
+
+// Creates a resource, POST path.
+type ResourceCreate interface {
+	Create(w http.ResponseWriter, r *http.Request)
+}
+
+// Shows the form for editing a resource, GET path/{id}/edit.
+type ResourceEdit interface {
+	Edit(w http.ResponseWriter, r *http.Request)
+}
+
+// Updates a resource, PUT or PATCH path/{id}.
+type ResourceUpdate interface {
+	Update(w http.ResponseWriter, r *http.Request)
+}
+
+// Deletes a resource, DELETE path/{id}.
+type ResourceDelete interface {
+	Delete(w http.ResponseWriter, r *http.Request)
+}
+
+// Register the conventional routes for the controller methods it implements.  Routes are named after the path,
+// "/admin/posts" gives "admin.posts.index", "admin.posts.show" and so on, and the resource id is the {id} variable.
+// Turn on SetMethodOverride so html forms can update and delete.
+func (m *Milo) Resource(path string, controller interface{}) []*MiloRoute {
+	return registerResource(m.Route, resourceName(path), path, controller)
+}
+
+// Register the conventional routes for the controller inside the group, names include the group prefix.
+func (g *Group) Resource(path string, controller interface{}) []*MiloRoute {
+	return registerResource(g.Route, resourceName(g.prefix+path), path, controller)
+}
+
+// Register the resource routes through the route function, new is registered before show so it isn't taken as an id.
+func registerResource(route func(string, []string, http.HandlerFunc, ...Middleware) *MiloRoute, name, path string, controller interface{}) []*MiloRoute {
+	path = strings.TrimSuffix(path, "/")
+	member := path + "/{id}"
+	routes := make([]*MiloRoute, 0)
+	if c, ok := controller.(ResourceIndex); ok {
+		routes = append(routes, route(path, []string{http.MethodGet}, c.Index).Name(name+".index"))
+	}
+	if c, ok := controller.(ResourceCreate); ok {
+		routes = append(routes, route(path, []string{http.MethodPost}, c.Create).Name(name+".create"))
+	}
+	if c, ok := controller.(ResourceNew); ok {
+		routes = append(routes, route(path+"/new", []string{http.MethodGet}, c.New).Name(name+".new"))
+	}
+	if c, ok := controller.(ResourceShow); ok {
+		routes = append(routes, route(member, []string{http.MethodGet}, c.Show).Name(name+".show"))
+	}
+	if c, ok := controller.(ResourceEdit); ok {
+		routes = append(routes, route(member+"/edit", []string{http.MethodGet}, c.Edit).Name(name+".edit"))
+	}
+	if c, ok := controller.(ResourceUpdate); ok {
+		routes = append(routes, route(member, []string{http.MethodPut, http.MethodPatch}, c.Update).Name(name+".update"))
+	}
+	if c, ok := controller.(ResourceDelete); ok {
+		routes = append(routes, route(member, []string{http.MethodDelete}, c.Delete).Name(name+".delete"))
+	}
+	return routes
+}
+
+// The route name prefix for a resource path, "/admin/posts" gives "admin.posts".
+func resourceName(path string) string {
+	parts := make([]string, 0)
+	for _, part := range strings.Split(path, "/") {
+		if part != "" && !strings.HasPrefix(part, "{") {
+			parts = append(parts, part)
+		}
+	}
+	return strings.Join(parts, ".")
+}
+
+// Swap the method of a POST for the one in the X-HTTP-Method-Override header or _method form field, only PUT,
+// PATCH and DELETE can be requested.  The form field is only read from url encoded bodies, multipart bodies are
+// left for the handler to parse.
+func (m *Milo) overrideMethod(r *http.Request) *http.Request {
+	if !m.methodOverride || r.Method != http.MethodPost {
+		return r
+	}
+	method := r.Header.Get(methodOverrideHeader)
+	if mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type")); method == "" && mediaType == "application/x-www-form-urlencoded" {
+		if err := r.ParseForm(); err == nil {
+			method = r.PostForm.Get(methodOverrideField)
+		}
+	}
+	switch method = strings.ToUpper(method); method {
+	case http.MethodPut, http.MethodPatch, http.MethodDelete:
+		override := *r
+		override.Method = method
+		return &override
+	}
+	return r
+}
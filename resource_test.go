@@ -0,0 +1,54 @@
+package milo_test
+
+import (
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/kendellfab/milo"
+	"github.com/kendellfab/milo/milotest"
+)
+
+type postsController struct{}
+
+func (postsController) Show(w http.ResponseWriter, r *http.Request) {
+	w.Write([]byte("show " + milo.Param(r, "id")))
+}
+
+func (postsController) Update(w http.ResponseWriter, r *http.Request) {
+	w.Write([]byte("update " + r.Method + " " + milo.Param(r, "id")))
+}
+
+func (postsController) Delete(w http.ResponseWriter, r *http.Request) {
+	w.Write([]byte("delete " + milo.Param(r, "id")))
+}
+
+func TestResourceMethodOverride(t *testing.T) {
+	app, err := milo.NewMilo(milo.SetMethodOverride(true))
+	if err != nil {
+		t.Fatal(err)
+	}
+	app.Group("/admin").Resource("/posts", postsController{})
+
+	c := milotest.NewClient(t, app.Handler())
+	c.Get("/admin/posts/3").AssertBodyContains("show 3")
+	c.PostForm("/admin/posts/3", url.Values{"_method": {"patch"}}).AssertBodyContains("update PATCH 3")
+	c.Send(milotest.NewRequest(http.MethodPost, "/admin/posts/3").Header("X-HTTP-Method-Override", "DELETE")).
+		AssertBodyContains("delete 3")
+	c.Send(milotest.NewRequest(http.MethodPost, "/admin/posts/3")).AssertStatus(http.StatusMethodNotAllowed)
+
+	if u, err := app.URL("admin.posts.show", "id", "9"); err != nil || u != "/admin/posts/9" {
+		t.Errorf("expected /admin/posts/9, got %q %v", u, err)
+	}
+}
+
+func TestResourceLeavesMethodOverrideOff(t *testing.T) {
+	app, err := milo.NewMilo()
+	if err != nil {
+		t.Fatal(err)
+	}
+	app.Resource("/posts", postsController{})
+
+	c := milotest.NewClient(t, app.Handler())
+	c.PostForm("/posts/3", url.Values{"_method": {"DELETE"}}).AssertStatus(http.StatusMethodNotAllowed)
+}
@@ -0,0 +1,116 @@
+package milo
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"time"
+)
+
+type responseKey struct{}
+
+// What has been written for the request so far, after middleware reads it for logging and metrics.
+type ResponseStats struct {
+	Status   int // The status sent, 200 when the handler wrote nothing.
+	Bytes    int64
+	Duration time.Duration // Time since milo started handling the request.
+	Written  bool          // The header has been sent.
+}
+
+// Get the response stats for the request, false outside of a milo route.
+func GetResponseStats(r *http.Request) (ResponseStats, bool) {
+	rw, ok := r.Context().Value(responseKey{}).(*responseWriter)
+	if !ok {
+		return ResponseStats{}, false
+	}
+	stats := ResponseStats{Status: rw.status, Bytes: rw.bytes, Duration: time.Since(rw.start), Written: rw.status != 0}
+	if stats.Status == 0 {
+		stats.Status = http.StatusOK
+	}
+	return stats, true
+}
+
+// Check the response has been started on the writer, including the buffered writer of a route with a timeout.
+func responseStarted(w http.ResponseWriter, r *http.Request) bool {
+	switch rw := w.(type) {
+	case *timeoutWriter:
+		rw.Lock()
+		defer rw.Unlock()
+		return rw.code != 0
+	case *responseWriter:
+		return rw.status != 0
+	}
+	stats, ok := GetResponseStats(r)
+	return ok && stats.Written
+}
+
+// Records the status, size and timing of the response.
+type responseWriter struct {
+	http.ResponseWriter
+	status   int
+	bytes    int64
+	start    time.Time
+	hijacked bool
+}
+
+// Wrap the writer and add it to the request context, a request which is already wrapped keeps its writer.
+func wrapResponse(w http.ResponseWriter, r *http.Request) (http.ResponseWriter, *http.Request) {
+	if rw, ok := r.Context().Value(responseKey{}).(*responseWriter); ok && rw == w {
+		return w, r
+	}
+	rw := &responseWriter{ResponseWriter: w, start: time.Now()}
+	return rw, r.WithContext(context.WithValue(r.Context(), responseKey{}, rw))
+}
+
+func (rw *responseWriter) WriteHeader(code int) {
+	if rw.status == 0 {
+		rw.status = code
+	}
+	rw.ResponseWriter.WriteHeader(code)
+}
+
+func (rw *responseWriter) Write(p []byte) (int, error) {
+	if rw.status == 0 {
+		rw.status = http.StatusOK
+	}
+	n, err := rw.ResponseWriter.Write(p)
+	rw.bytes += int64(n)
+	return n, err
+}
+
+func (rw *responseWriter) Flush() {
+	if rw.status == 0 {
+		rw.status = http.StatusOK
+	}
+	if f, ok := rw.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+func (rw *responseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	hj, ok := rw.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, errors.New("milo: response writer doesn't support hijacking")
+	}
+	conn, buf, err := hj.Hijack()
+	if err == nil {
+		rw.hijacked = true
+		if rw.status == 0 {
+			rw.status = http.StatusSwitchingProtocols
+		}
+	}
+	return conn, buf, err
+}
+
+// Check the connection was taken over, such as by a websocket, so nothing more can be written.
+func hijacked(w http.ResponseWriter) bool {
+	rw, ok := w.(*responseWriter)
+	return ok && rw.hijacked
+}
+
+// The wrapped writer, for http.ResponseController.
+func (rw *responseWriter) Unwrap() http.ResponseWriter {
+	return rw.ResponseWriter
+}
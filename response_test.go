@@ -0,0 +1,66 @@
+package milo_test
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/kendellfab/milo"
+	"github.com/kendellfab/milo/milotest"
+)
+
+func TestAfterMiddlewareRunsOnEveryOutcome(t *testing.T) {
+	app, err := milo.NewMilo()
+	if err != nil {
+		t.Fatal(err)
+	}
+	app.Use(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path == "/unauthorized" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	})
+	app.RegisterBefore(func(w http.ResponseWriter, r *http.Request) bool {
+		if r.URL.Path == "/forbidden" {
+			w.WriteHeader(http.StatusForbidden)
+			return false
+		}
+		return true
+	})
+	stats := make(map[string]milo.ResponseStats)
+	app.RegisterAfter(func(w http.ResponseWriter, r *http.Request) bool {
+		stats[r.URL.Path], _ = milo.GetResponseStats(r)
+		return true
+	})
+	app.Route("/ok", []string{http.MethodGet}, okHandler)
+	app.Route("/forbidden", []string{http.MethodGet}, okHandler)
+	app.Route("/unauthorized", []string{http.MethodGet}, okHandler)
+	app.Route("/panic", []string{http.MethodGet}, func(w http.ResponseWriter, r *http.Request) {
+		panic("handler failed")
+	})
+
+	c := milotest.NewClient(t, app.Handler())
+	expected := map[string]int{
+		"/ok":           http.StatusOK,
+		"/forbidden":    http.StatusForbidden,
+		"/unauthorized": http.StatusUnauthorized,
+		"/panic":        http.StatusInternalServerError,
+		"/missing":      http.StatusNotFound,
+	}
+	for path, code := range expected {
+		c.Get(path).AssertStatus(code)
+		got, ok := stats[path]
+		if !ok {
+			t.Errorf("expected after middleware to run for %s", path)
+			continue
+		}
+		if got.Status != code {
+			t.Errorf("expected %s stats status %d, got %d", path, code, got.Status)
+		}
+	}
+	if stats["/ok"].Bytes != 2 {
+		t.Errorf("expected 2 bytes written for /ok, got %d", stats["/ok"].Bytes)
+	}
+}
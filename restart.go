@@ -0,0 +1,82 @@
+package milo
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// A listener which can hand its socket over to another process.
+type fileListener interface {
+	File() (*os.File, error)
+}
+
+// Start a new process of the running binary with the listening sockets handed over.  The old process keeps its
+// listeners open until it shuts down, so connections are accepted by one or the other throughout.
+func (m *Milo) restart() error {
+	m.mu.Lock()
+	listeners := []net.Listener{m.listener}
+	names := []string{mainListenerName}
+	if m.redirectListener != nil {
+		listeners = append(listeners, m.redirectListener)
+		names = append(names, redirectListenerName)
+	}
+	for _, lc := range m.listeners {
+		listeners = append(listeners, lc.ln)
+		names = append(names, lc.name)
+	}
+	m.mu.Unlock()
+
+	files := make([]*os.File, 0, len(listeners))
+	defer func() {
+		for _, f := range files {
+			f.Close()
+		}
+	}()
+	for _, ln := range listeners {
+		fl, ok := ln.(fileListener)
+		if !ok {
+			return fmt.Errorf("milo: listener %s can't be handed over", ln.Addr())
+		}
+		f, err := fl.File()
+		if err != nil {
+			return err
+		}
+		files = append(files, f)
+	}
+
+	path, err := os.Executable()
+	if err != nil {
+		return err
+	}
+	for _, name := range names {
+		if strings.Contains(name, ":") {
+			return errors.New("milo: listener names can't contain a colon: " + name)
+		}
+	}
+
+	cmd := exec.Command(path, os.Args[1:]...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	cmd.ExtraFiles = files
+	cmd.Env = append(os.Environ(),
+		fmt.Sprintf("%s=%d", envListenFds, len(files)),
+		fmt.Sprintf("%s=%s", envListenFdNames, strings.Join(names, ":")),
+	)
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	m.logger.LogInterfaces("Started new process:", cmd.Process.Pid)
+
+	// The socket file now belongs to the new process, don't remove it when the old listener closes.
+	for _, ln := range listeners {
+		if ul, ok := ln.(*net.UnixListener); ok {
+			ul.SetUnlinkOnClose(false)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,12 @@
+//go:build !unix
+
+package milo
+
+import (
+	"os"
+)
+
+// Graceful restart isn't supported on this platform, the channel never fires.
+func restartSignals() (<-chan os.Signal, func()) {
+	return nil, func() {}
+}
@@ -0,0 +1,16 @@
+//go:build unix
+
+package milo
+
+import (
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+// Signals which trigger a graceful restart.
+func restartSignals() (<-chan os.Signal, func()) {
+	ch := make(chan os.Signal, 1)
+	signal.Notify(ch, syscall.SIGHUP, syscall.SIGUSR2)
+	return ch, func() { signal.Stop(ch) }
+}
@@ -0,0 +1,140 @@
+package milo
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"runtime/debug"
+	"sync"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// A route registered with the milo app, returned so route options can be chained on.
+type MiloRoute struct {
+	milo       *Milo
+	group      *Group
+	path       string
+	name       string
+	kind       string
+	methods    []string
+	timeout    time.Duration
+	paramTypes map[string]string
+	middleware []Middleware
+	bare       bool
+	route      *mux.Route
+	subtree    *mux.Route // The route for the paths below a mount prefix.
+}
+
+// Serve a websocket or asset route straight from the router, skipping the logging, middleware and panic recovery
+// of the milo pipeline.  Meant for hot static paths.
+func (mr *MiloRoute) Bare() *MiloRoute {
+	mr.bare = true
+	return mr
+}
+
+// Set a deadline for the route handler.  The request context is cancelled once the timeout passes and when the
+// handler overruns a 503 is rendered through the registered renderer.  The response is buffered until the handler
+// returns, so don't use it on streaming routes.
+func (mr *MiloRoute) Timeout(timeout time.Duration) *MiloRoute {
+	mr.timeout = timeout
+	return mr
+}
+
+// Wraps the handler so it is run with the route deadline.
+func (m *Milo) timeoutHandler(mr *MiloRoute, hf http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if mr.timeout <= 0 {
+			hf(w, r)
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(r.Context(), mr.timeout)
+		defer cancel()
+		r = r.WithContext(ctx)
+
+		tw := &timeoutWriter{header: make(http.Header)}
+		done := make(chan struct{})
+		panicked := make(chan interface{}, 1)
+		go func() {
+			defer func() {
+				if err := recover(); err != nil {
+					if _, ok := err.(*ParamError); ok {
+						panicked <- err
+						return
+					}
+					// Log here while the handler's stack is available, the panic is lost once the route timed out.
+					m.logger.LogInterfaces("milo.Route", r.URL.RequestURI(), err, mux.Vars(r))
+					m.logger.Log(string(debug.Stack()))
+					panicked <- &loggedPanic{value: err}
+				}
+			}()
+			hf(tw, r)
+			close(done)
+		}()
+
+		select {
+		case err := <-panicked:
+			// Re-panic on the request goroutine so the error handler can recover it.
+			panic(err)
+		case <-done:
+			tw.Lock()
+			defer tw.Unlock()
+			dst := w.Header()
+			for k, v := range tw.header {
+				dst[k] = v
+			}
+			if tw.code == 0 {
+				tw.code = http.StatusOK
+			}
+			w.WriteHeader(tw.code)
+			w.Write(tw.buf.Bytes())
+		case <-ctx.Done():
+			tw.Lock()
+			tw.timedOut = true
+			tw.Unlock()
+			m.logger.Log("503 - Route timed out.  " + r.RequestURI)
+			m.renderStatus(w, r, http.StatusServiceUnavailable, "Service Unavailable")
+		}
+	}
+}
+
+// A handler panic which has already been logged with its stack.
+type loggedPanic struct {
+	value interface{}
+}
+
+// Buffers the handler response so it can be dropped when the handler overruns.
+type timeoutWriter struct {
+	header   http.Header
+	buf      bytes.Buffer
+	code     int
+	timedOut bool
+	sync.Mutex
+}
+
+func (tw *timeoutWriter) Header() http.Header {
+	return tw.header
+}
+
+func (tw *timeoutWriter) Write(p []byte) (int, error) {
+	tw.Lock()
+	defer tw.Unlock()
+	if tw.timedOut {
+		return 0, http.ErrHandlerTimeout
+	}
+	if tw.code == 0 {
+		tw.code = http.StatusOK
+	}
+	return tw.buf.Write(p)
+}
+
+func (tw *timeoutWriter) WriteHeader(code int) {
+	tw.Lock()
+	defer tw.Unlock()
+	if tw.timedOut || tw.code != 0 {
+		return
+	}
+	tw.code = code
+}
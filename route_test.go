@@ -0,0 +1,94 @@
+package milo_test
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/kendellfab/milo"
+	"github.com/kendellfab/milo/milotest"
+)
+
+// Captures log output so tests can check what was logged.
+type captureLogger struct {
+	lines []string
+	sync.Mutex
+}
+
+func (l *captureLogger) Log(message string) {
+	l.Lock()
+	defer l.Unlock()
+	l.lines = append(l.lines, message)
+}
+
+func (l *captureLogger) LogError(err error) {
+	l.Log(err.Error())
+}
+
+func (l *captureLogger) LogInterfaces(items ...interface{}) {
+	l.Log(fmt.Sprint(items...))
+}
+
+func (l *captureLogger) LogFatal(items ...interface{}) {
+	l.Log(fmt.Sprint(items...))
+}
+
+func (l *captureLogger) LogStackTrace() {}
+
+func (l *captureLogger) contains(s string) bool {
+	l.Lock()
+	defer l.Unlock()
+	for _, line := range l.lines {
+		if strings.Contains(line, s) {
+			return true
+		}
+	}
+	return false
+}
+
+func TestTimeoutRoutes(t *testing.T) {
+	app, err := milo.NewMilo()
+	if err != nil {
+		t.Fatal(err)
+	}
+	logger := &captureLogger{}
+	app.RegisterLogger(logger)
+	app.Route("/fast", []string{http.MethodGet}, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Fast", "yes")
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte("fast"))
+	}).Timeout(time.Second)
+	app.Route("/slow", []string{http.MethodGet}, func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+		w.Write([]byte("too late"))
+	}).Timeout(10 * time.Millisecond)
+	panicked := make(chan struct{})
+	app.Route("/late-panic", []string{http.MethodGet}, func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+		defer close(panicked)
+		panic("late failure")
+	}).Timeout(10 * time.Millisecond)
+	app.Route("/param/{id:int}", []string{http.MethodGet}, func(w http.ResponseWriter, r *http.Request) {
+		milo.QueryInt(r, "page", 1)
+	}).Timeout(time.Second)
+
+	c := milotest.NewClient(t, app.Handler())
+	resp := c.Get("/fast").AssertStatus(http.StatusCreated).AssertBodyContains("fast")
+	if resp.Header().Get("X-Fast") != "yes" {
+		t.Error("expected the buffered headers to be copied")
+	}
+	c.Get("/slow").AssertStatus(http.StatusServiceUnavailable)
+	c.Get("/late-panic").AssertStatus(http.StatusServiceUnavailable)
+	<-panicked
+	deadline := time.Now().Add(time.Second)
+	for !logger.contains("late failure") && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if !logger.contains("late failure") || !logger.contains("goroutine") {
+		t.Error("expected the late panic to be logged with its stack")
+	}
+	c.Get("/param/1?page=x").AssertStatus(http.StatusBadRequest)
+}
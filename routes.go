@@ -0,0 +1,136 @@
+package milo
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"runtime"
+	"strings"
+	"text/tabwriter"
+)
+
+const (
+	KindRoute     = "route"
+	KindPrefix    = "prefix"
+	KindWebsocket = "websocket"
+	KindAsset     = "asset"
+	KindMount     = "mount"
+)
+
+// Details of a registered route.
+type RouteInfo struct {
+	Host       string   `json:"host,omitempty"`
+	Path       string   `json:"path"`
+	Methods    []string `json:"methods"`
+	Name       string   `json:"name,omitempty"`
+	Kind       string   `json:"kind"`
+	Middleware []string `json:"middleware"`
+}
+
+// List the registered routes in registration order, with the middleware which runs for each.
+func (m *Milo) Routes() []RouteInfo {
+	m.mu.Lock()
+	routes := append([]*MiloRoute{}, m.routes...)
+	m.mu.Unlock()
+
+	infos := make([]RouteInfo, 0, len(routes))
+	for _, mr := range routes {
+		methods := mr.methods
+		if methods == nil {
+			methods = []string{"*"}
+		}
+		infos = append(infos, RouteInfo{
+			Host:       mr.group.hostPattern(),
+			Path:       mr.path,
+			Methods:    methods,
+			Name:       mr.name,
+			Kind:       mr.kind,
+			Middleware: m.middlewareNames(mr),
+		})
+	}
+	return infos
+}
+
+// Setup a page listing the registered routes, json when requested with an application/json accept header.
+// Meant for development, don't expose it in production.
+func (m *Milo) RouteDebugRoutes(path string) *MiloRoute {
+	return m.Route(path, []string{http.MethodGet}, func(w http.ResponseWriter, r *http.Request) {
+		if wantsJSON(r) {
+			data, err := json.Marshal(m.Routes())
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+			w.Header().Set("Content-Type", "application/json")
+			w.Write(data)
+			return
+		}
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		w.Write([]byte(m.routesTable()))
+	})
+}
+
+// Add the route to the registry used for introspection.
+func (m *Milo) addRoute(mr *MiloRoute) *MiloRoute {
+	m.mu.Lock()
+	m.routes = append(m.routes, mr)
+	m.mu.Unlock()
+	return mr
+}
+
+// Format the routes as a text table.
+func (m *Milo) routesTable() string {
+	var buf bytes.Buffer
+	tw := tabwriter.NewWriter(&buf, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "KIND\tMETHODS\tHOST\tPATH\tNAME\tMIDDLEWARE")
+	for _, info := range m.Routes() {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\n", info.Kind, strings.Join(info.Methods, ","), info.Host, info.Path, info.Name, strings.Join(info.Middleware, ", "))
+	}
+	tw.Flush()
+	return buf.String()
+}
+
+// The names of the middleware functions which run for the route, in the order they run.
+func (m *Milo) middlewareNames(mr *MiloRoute) []string {
+	names := make([]string, 0)
+	if mr.bare {
+		return names
+	}
+	for _, mw := range m.middleware {
+		names = append(names, "use:"+funcName(mw))
+	}
+	for _, mw := range m.beforeMiddleware {
+		names = append(names, "before:"+funcName(mw))
+	}
+	for _, group := range mr.group.chain() {
+		for _, mw := range group.beforeMiddleware {
+			names = append(names, "before:"+funcName(mw))
+		}
+	}
+	for _, mw := range mr.middleware {
+		names = append(names, "route:"+funcName(mw))
+	}
+	for group := mr.group; group != nil; group = group.parent {
+		for _, mw := range group.afterMiddleware {
+			names = append(names, "after:"+funcName(mw))
+		}
+	}
+	for _, mw := range m.afterMiddleware {
+		names = append(names, "after:"+funcName(mw))
+	}
+	return names
+}
+
+// The package qualified name of a function.
+func funcName(fn interface{}) string {
+	v := reflect.ValueOf(fn)
+	if v.Kind() != reflect.Func || v.IsNil() {
+		return "unknown"
+	}
+	if f := runtime.FuncForPC(v.Pointer()); f != nil {
+		return f.Name()
+	}
+	return "unknown"
+}
@@ -0,0 +1,352 @@
+package milo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"strconv"
+	"syscall"
+	"time"
+
+	"golang.org/x/net/websocket"
+)
+
+// Binds and runs the application on the given config port.  Blocks until a SIGINT or SIGTERM is received,
+// then drains in flight requests before returning.
+func (m *Milo) Run() error {
+	err := m.RunContext(context.Background())
+	if err != nil {
+		m.logger.LogError(err)
+	}
+	return err
+}
+
+// Binds and runs the application until the context is cancelled or a SIGINT/SIGTERM is received.
+// Once stopped the server quits accepting connections and waits up to the drain timeout for active
+// handlers and websocket connections to finish.
+func (m *Milo) RunContext(ctx context.Context) error {
+	ctx, stop := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if err := m.ValidateRoutes(); err != nil {
+		return err
+	}
+	if m.logRoutes {
+		m.logger.Log("Routes:\n" + m.routesTable())
+	}
+	if err := m.Listen(); err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	ln := m.listener
+	redirectLn := m.redirectListener
+	extras := m.listeners
+	m.mu.Unlock()
+	log.Println("Connection:", ln.Addr())
+	srv := m.getHTTPServer(ln.Addr().String(), m.Handler())
+	if m.tlsEnabled() {
+		var err error
+		if srv.TLSConfig, err = m.getTLSConfig(); err != nil {
+			m.closeListeners()
+			return err
+		}
+	}
+	servers := []*http.Server{srv}
+	listeners := []net.Listener{ln}
+
+	if redirectLn != nil {
+		log.Println("Redirect:", redirectLn.Addr())
+		servers = append(servers, m.getRedirectServer())
+		listeners = append(listeners, redirectLn)
+	}
+
+	for _, lc := range extras {
+		log.Println("Connection:", lc.name, lc.ln.Addr())
+		servers = append(servers, m.getHTTPServer(lc.ln.Addr().String(), lc.handler))
+		listeners = append(listeners, lc.ln)
+	}
+
+	m.mu.Lock()
+	m.servers = servers
+	shuttingDown := m.shuttingDown
+	m.mu.Unlock()
+	if shuttingDown {
+		// Shutdown ran before the servers were stored so it couldn't stop them, don't start serving.
+		m.closeListeners()
+		<-m.shutdownDone
+		return nil
+	}
+
+	serveErr := make(chan error, len(servers))
+	for i := range servers {
+		go func(srv *http.Server, ln net.Listener) {
+			if srv.TLSConfig != nil {
+				serveErr <- srv.ServeTLS(ln, "", "")
+			} else {
+				serveErr <- srv.Serve(ln)
+			}
+		}(servers[i], listeners[i])
+	}
+
+	var restart <-chan os.Signal
+	if m.gracefulRestart {
+		var stopRestart func()
+		restart, stopRestart = restartSignals()
+		defer stopRestart()
+	}
+
+wait:
+	for {
+		select {
+		case err := <-serveErr:
+			if errors.Is(err, http.ErrServerClosed) {
+				// Shutdown was called directly, wait for it to finish draining.
+				<-m.shutdownDone
+				return nil
+			}
+			shutdownCtx, cancel := context.WithTimeout(context.Background(), m.drainTimeout)
+			defer cancel()
+			m.Shutdown(shutdownCtx)
+			return err
+		case <-restart:
+			if err := m.restart(); err != nil {
+				m.logger.LogInterfaces("Restart failed:", err)
+				continue
+			}
+			m.logger.Log("Restarted, draining connections.")
+			break wait
+		case <-ctx.Done():
+			m.logger.Log("Shutting down, draining connections.")
+			break wait
+		}
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), m.drainTimeout)
+	defer cancel()
+	return m.Shutdown(shutdownCtx)
+}
+
+// Gracefully shuts down the server.  New connections are refused and active handlers and websocket
+// connections are given until the context is done to finish, after which they are closed.
+func (m *Milo) Shutdown(ctx context.Context) error {
+	m.mu.Lock()
+	servers := m.servers
+	m.shuttingDown = true
+	m.mu.Unlock()
+	defer m.shutdownOnce.Do(func() { close(m.shutdownDone) })
+
+	// Keep serving while readiness reports failing, so load balancers stop sending traffic first.
+	if m.shutdownDelay > 0 {
+		select {
+		case <-time.After(m.shutdownDelay):
+		case <-ctx.Done():
+		}
+	}
+
+	var err error
+	for _, srv := range servers {
+		if srvErr := srv.Shutdown(ctx); err == nil {
+			err = srvErr
+		}
+	}
+	if wsErr := m.drainWebsockets(ctx); err == nil {
+		err = wsErr
+	}
+	if err != nil {
+		for _, srv := range servers {
+			srv.Close()
+		}
+		return fmt.Errorf("milo: shutdown incomplete: %w", err)
+	}
+	return nil
+}
+
+// Reports if the application has begun shutting down.
+func (m *Milo) isShuttingDown() bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.shuttingDown
+}
+
+// Binds the listeners for the configured addresses without serving, so the bound address is available from Addr
+// before Run is called.  Calling Listen again after a successful bind is a no-op.
+func (m *Milo) Listen() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if err := m.bindListeners(); err != nil {
+		// Don't leave the listeners bound before the failure open.
+		m.closeOpenListeners()
+		return err
+	}
+	return nil
+}
+
+// Bind the main, redirect and extra listeners which aren't open yet.
+func (m *Milo) bindListeners() error {
+	// Sockets handed over from a restarting parent process take priority over systemd.
+	if inherited, names, err := inheritedListeners(); err != nil {
+		return err
+	} else if inherited != nil {
+		m.assignListeners(inherited, names)
+	} else if m.socketActivation {
+		activated, names, err := systemdListeners()
+		if err != nil {
+			return err
+		}
+		m.assignListeners(activated, names)
+	}
+
+	if m.listener == nil {
+		ln, err := m.listen()
+		if err != nil {
+			return err
+		}
+		m.listener = ln
+	}
+	if m.tlsEnabled() && m.redirectPort > 0 && m.redirectListener == nil {
+		ln, err := net.Listen("tcp", m.getConnectionString(m.redirectPort))
+		if err != nil {
+			return fmt.Errorf("milo: redirect listener: %w", err)
+		}
+		m.redirectListener = ln
+	}
+	for _, lc := range m.listeners {
+		if lc.ln != nil {
+			continue
+		}
+		var ln net.Listener
+		var err error
+		if lc.network == "unix" {
+			ln, err = listenUnix(lc.addr)
+		} else {
+			ln, err = net.Listen(lc.network, lc.addr)
+		}
+		if err != nil {
+			return fmt.Errorf("milo: listener %s: %w", lc.name, err)
+		}
+		lc.ln = ln
+	}
+	return nil
+}
+
+// Close any open listeners, used when startup fails after binding.
+func (m *Milo) closeListeners() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.closeOpenListeners()
+}
+
+// Close and forget the open listeners, so a later Listen binds them again.  The caller holds the lock.
+func (m *Milo) closeOpenListeners() {
+	if m.listener != nil {
+		m.listener.Close()
+		m.listener = nil
+	}
+	if m.redirectListener != nil {
+		m.redirectListener.Close()
+		m.redirectListener = nil
+	}
+	for _, lc := range m.listeners {
+		if lc.ln != nil {
+			lc.ln.Close()
+			lc.ln = nil
+		}
+	}
+}
+
+// The address the application is bound to, nil until the listener is open.
+func (m *Milo) Addr() net.Addr {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.listener == nil {
+		return nil
+	}
+	return m.listener.Addr()
+}
+
+// Open the listener for the configured bind address or unix socket.  With port increment set, ports are walked
+// upwards until a free one is found.  Port 0 binds an ephemeral port chosen by the os.
+func (m *Milo) listen() (net.Listener, error) {
+	if m.unixSocket != "" {
+		return listenUnix(m.unixSocket)
+	}
+	port := m.port
+	for {
+		ln, err := net.Listen("tcp", m.getConnectionString(port))
+		if err == nil {
+			return ln, nil
+		}
+		if m.portIncrement && port != 0 && port < maxPort && errors.Is(err, syscall.EADDRINUSE) {
+			port++
+			continue
+		}
+		return nil, err
+	}
+}
+
+func (m *Milo) getHTTPServer(addr string, h http.Handler) *http.Server {
+	return &http.Server{
+		Addr:              addr,
+		Handler:           h,
+		ReadTimeout:       m.readTimeout,
+		ReadHeaderTimeout: m.readHeaderTimeout,
+		WriteTimeout:      m.writeTimeout,
+		IdleTimeout:       m.idleTimeout,
+		MaxHeaderBytes:    m.maxHeaderBytes,
+	}
+}
+
+// Get the connection string for the bind address and port.
+func (m *Milo) getConnectionString(port int) string {
+	return net.JoinHostPort(m.bind, strconv.Itoa(port))
+}
+
+// Wraps a websocket handler so the connection is tracked for the life of the handler.
+func (m *Milo) trackWebsocket(hf func(ws *websocket.Conn)) func(ws *websocket.Conn) {
+	return func(ws *websocket.Conn) {
+		m.mu.Lock()
+		if m.shuttingDown {
+			m.mu.Unlock()
+			ws.Close()
+			return
+		}
+		m.websockets[ws] = struct{}{}
+		m.websocketWg.Add(1)
+		m.mu.Unlock()
+
+		defer func() {
+			m.mu.Lock()
+			delete(m.websockets, ws)
+			m.mu.Unlock()
+			m.websocketWg.Done()
+		}()
+		hf(ws)
+	}
+}
+
+// Wait for the tracked websocket connections to finish, closing any that remain once the context is done.
+func (m *Milo) drainWebsockets(ctx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		m.websocketWg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		m.mu.Lock()
+		for ws := range m.websockets {
+			ws.Close()
+		}
+		m.mu.Unlock()
+		return ctx.Err()
+	}
+}
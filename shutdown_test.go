@@ -0,0 +1,78 @@
+package milo_test
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/kendellfab/milo"
+)
+
+func runApp(t *testing.T, app *milo.Milo) <-chan error {
+	t.Helper()
+	done := make(chan error, 1)
+	go func() {
+		done <- app.RunContext(context.Background())
+	}()
+	return done
+}
+
+func waitRun(t *testing.T, done <-chan error) error {
+	t.Helper()
+	select {
+	case err := <-done:
+		return err
+	case <-time.After(5 * time.Second):
+		t.Fatal("RunContext did not return")
+		return nil
+	}
+}
+
+func TestShutdownStopsRun(t *testing.T) {
+	app, err := milo.NewMilo(milo.SetBind("127.0.0.1"), milo.SetPort(0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	app.Route("/", []string{http.MethodGet}, okHandler)
+	done := runApp(t, app)
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		if addr := app.Addr(); addr != nil {
+			resp, err := http.Get("http://" + addr.String() + "/")
+			if err == nil {
+				resp.Body.Close()
+				break
+			}
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("app never started serving")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	if err := app.Shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if err := waitRun(t, done); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestShutdownBeforeRun(t *testing.T) {
+	app, err := milo.NewMilo(milo.SetBind("127.0.0.1"), milo.SetPort(0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	app.Route("/", []string{http.MethodGet}, okHandler)
+	if err := app.Shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if err := waitRun(t, runApp(t, app)); err != nil {
+		t.Fatal(err)
+	}
+	if addr := app.Addr(); addr != nil {
+		t.Errorf("expected the listener to be closed, still bound to %s", addr)
+	}
+}
@@ -0,0 +1,175 @@
+package milo
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"errors"
+	"math/big"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	certCheckInterval = time.Second
+)
+
+// Reports if the application should be served over https.
+func (m *Milo) tlsEnabled() bool {
+	return m.devTLS || m.certFile != ""
+}
+
+// Build the tls config for the server, either from the configured certificate files or a generated self signed certificate.
+func (m *Milo) getTLSConfig() (*tls.Config, error) {
+	config := &tls.Config{MinVersion: tls.VersionTLS12}
+	if m.devTLS {
+		cert, err := generateSelfSignedCert(m.bind)
+		if err != nil {
+			return nil, err
+		}
+		m.logger.Log("Serving with a generated self signed certificate, development use only.")
+		config.Certificates = []tls.Certificate{cert}
+		return config, nil
+	}
+
+	if m.certFile == "" || m.keyFile == "" {
+		return nil, errors.New("milo: tls requires both a certificate and key file")
+	}
+	cr, err := newCertReloader(m.certFile, m.keyFile, m.certReload, m.logger)
+	if err != nil {
+		return nil, err
+	}
+	config.GetCertificate = cr.GetCertificate
+	return config, nil
+}
+
+// Server which sends all plain http requests to the https listener.
+func (m *Milo) getRedirectServer() *http.Server {
+	return m.getHTTPServer(m.getConnectionString(m.redirectPort), http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		host := Host(r)
+		if h, _, err := net.SplitHostPort(host); err == nil {
+			host = h
+		}
+		port := m.port
+		if addr, ok := m.Addr().(*net.TCPAddr); ok {
+			port = addr.Port
+		}
+		if port != 443 {
+			host = net.JoinHostPort(host, strconv.Itoa(port))
+		}
+		http.Redirect(w, r, "https://"+host+r.URL.RequestURI(), http.StatusMovedPermanently)
+	}))
+}
+
+// Serves a certificate pair from disk, reloading it when the files change.
+type certReloader struct {
+	certFile string
+	keyFile  string
+	reload   bool
+	logger   MiloLogger
+	cert     *tls.Certificate
+	certMod  time.Time
+	keyMod   time.Time
+	checked  time.Time
+	sync.Mutex
+}
+
+// Create a new cert reloader, the certificate pair is loaded up front so bad files are caught at startup.
+func newCertReloader(certFile, keyFile string, reload bool, logger MiloLogger) (*certReloader, error) {
+	cr := &certReloader{certFile: certFile, keyFile: keyFile, reload: reload, logger: logger}
+	if err := cr.load(); err != nil {
+		return nil, err
+	}
+	return cr, nil
+}
+
+// Certificate callback for the tls config.
+func (cr *certReloader) GetCertificate(hello *tls.ClientHelloInfo) (*tls.Certificate, error) {
+	cr.Lock()
+	defer cr.Unlock()
+
+	if cr.reload && time.Since(cr.checked) > certCheckInterval {
+		cr.checked = time.Now()
+		if cr.changed() {
+			// Keep serving the previous certificate when the new pair is bad or only partially written.
+			if err := cr.load(); err != nil {
+				cr.logger.LogInterfaces("Certificate reload failed:", err)
+			} else {
+				cr.logger.Log("Certificate reloaded: " + cr.certFile)
+			}
+		}
+	}
+	return cr.cert, nil
+}
+
+// Check the modification times of the certificate pair.
+func (cr *certReloader) changed() bool {
+	certInfo, certErr := os.Stat(cr.certFile)
+	keyInfo, keyErr := os.Stat(cr.keyFile)
+	if certErr != nil || keyErr != nil {
+		return false
+	}
+	return !certInfo.ModTime().Equal(cr.certMod) || !keyInfo.ModTime().Equal(cr.keyMod)
+}
+
+// Load the certificate pair from disk.
+func (cr *certReloader) load() error {
+	certInfo, err := os.Stat(cr.certFile)
+	if err != nil {
+		return err
+	}
+	keyInfo, err := os.Stat(cr.keyFile)
+	if err != nil {
+		return err
+	}
+	cert, err := tls.LoadX509KeyPair(cr.certFile, cr.keyFile)
+	if err != nil {
+		return err
+	}
+	cr.cert = &cert
+	cr.certMod = certInfo.ModTime()
+	cr.keyMod = keyInfo.ModTime()
+	return nil
+}
+
+// Generate a self signed certificate for localhost and the bind address.
+func generateSelfSignedCert(bind string) (tls.Certificate, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+
+	tpl := &x509.Certificate{
+		SerialNumber:          serial,
+		Subject:               pkix.Name{Organization: []string{"Milo Development"}},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(365 * 24 * time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		BasicConstraintsValid: true,
+		DNSNames:              []string{"localhost"},
+		IPAddresses:           []net.IP{net.IPv4(127, 0, 0, 1), net.IPv6loopback},
+	}
+	if ip := net.ParseIP(bind); ip != nil && !ip.IsUnspecified() {
+		tpl.IPAddresses = append(tpl.IPAddresses, ip)
+	} else if bind != "" && ip == nil {
+		tpl.DNSNames = append(tpl.DNSNames, bind)
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, tpl, tpl, &key.PublicKey, key)
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}, nil
+}
@@ -0,0 +1,182 @@
+package milo
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"text/template/parse"
+)
+
+// Builds urls for named routes.
+type URLBuilder interface {
+	URL(name string, params ...string) (string, error)
+}
+
+// A url template function call found in a template.
+type urlReference struct {
+	template string
+	name     string
+	params   int
+}
+
+// Name the route so its url can be built with Milo.URL and the url template function.
+func (mr *MiloRoute) Name(name string) *MiloRoute {
+	if mr.name == name {
+		return mr
+	}
+	if mr.name != "" {
+		// Gorilla stops matching a route which is named twice.
+		mr.milo.routeErrors = append(mr.milo.routeErrors, fmt.Errorf("milo: route %s is already named %q, can't rename it %q", mr.path, mr.name, name))
+		return mr
+	}
+	if existing, ok := mr.milo.routeNames[name]; ok && existing != mr {
+		mr.milo.routeErrors = append(mr.milo.routeErrors, fmt.Errorf("milo: route name %q used by %s and %s", name, existing.path, mr.path))
+		return mr
+	}
+	mr.name = name
+	mr.milo.routeNames[name] = mr
+	mr.route.Name(name)
+	return mr
+}
+
+// Build the url for a named route, params are the route variable key & value pairs.
+func (m *Milo) URL(name string, params ...string) (string, error) {
+	route := m.router.Get(name)
+	if route == nil {
+		return "", fmt.Errorf("milo: no route named %q", name)
+	}
+	u, err := route.URL(params...)
+	if err != nil {
+		return "", fmt.Errorf("milo: route %q: %w", name, err)
+	}
+	return u.String(), nil
+}
+
+// Check the routes and the url references in the registered renderer's templates, so a renamed route or missing
+// route variable fails at startup instead of when the page is rendered.  Run calls this before binding.
+func (m *Milo) ValidateRoutes() error {
+	errs := append([]error{}, m.routeErrors...)
+	if m.renderer != nil && m.renderer.hasTemplates() {
+		refs, err := m.renderer.urlReferences()
+		if err != nil {
+			errs = append(errs, err)
+		}
+		for _, ref := range refs {
+			route := m.router.Get(ref.name)
+			if route == nil {
+				errs = append(errs, fmt.Errorf("milo: template %s: no route named %q", ref.template, ref.name))
+				continue
+			}
+			vars, err := route.GetVarNames()
+			if err == nil && ref.params != len(vars)*2 {
+				errs = append(errs, fmt.Errorf("milo: template %s: route %q takes %d params, got %d", ref.template, ref.name, len(vars)*2, ref.params))
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Find the url template function calls with a literal route name in the templates.
+func (mr *Renderer) urlReferences() ([]urlReference, error) {
+	var refs []urlReference
+	fsys := mr.templateFS()
+	err := fs.WalkDir(fsys, ".", func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		data, err := fs.ReadFile(fsys, path)
+		if err != nil {
+			return err
+		}
+		name := mr.tplPath(path)
+		tree := parse.New(name)
+		tree.Mode = parse.SkipFuncCheck
+		trees := make(map[string]*parse.Tree)
+		if _, err := tree.Parse(string(data), "", "", trees); err != nil {
+			// Not a template, or a broken one which rendering will report.
+			return nil
+		}
+		for _, t := range trees {
+			refs = append(refs, findURLCalls(name, t.Root)...)
+		}
+		return nil
+	})
+	return refs, err
+}
+
+// Check there are templates to scan, a renderer without a template directory or file system has none.
+func (mr *Renderer) hasTemplates() bool {
+	if mr.fsys != nil {
+		return true
+	}
+	if mr.tplDir == "" {
+		return false
+	}
+	info, err := os.Stat(mr.tplDir)
+	return err == nil && info.IsDir()
+}
+
+// Walk the template nodes looking for url calls.
+func findURLCalls(tpl string, node parse.Node) []urlReference {
+	var refs []urlReference
+	switch n := node.(type) {
+	case *parse.ListNode:
+		if n == nil {
+			return nil
+		}
+		for _, child := range n.Nodes {
+			refs = append(refs, findURLCalls(tpl, child)...)
+		}
+	case *parse.ActionNode:
+		refs = append(refs, findURLCalls(tpl, n.Pipe)...)
+	case *parse.PipeNode:
+		if n == nil {
+			return nil
+		}
+		for i, cmd := range n.Cmds {
+			if ref, ok := urlCall(tpl, cmd); ok {
+				// Commands after the first in a pipe get the previous value as their last argument.
+				if i > 0 {
+					ref.params++
+				}
+				refs = append(refs, ref)
+			}
+			refs = append(refs, findURLCalls(tpl, cmd)...)
+		}
+	case *parse.CommandNode:
+		for _, arg := range n.Args {
+			refs = append(refs, findURLCalls(tpl, arg)...)
+		}
+	case *parse.IfNode:
+		refs = append(refs, findBranchURLCalls(tpl, &n.BranchNode)...)
+	case *parse.RangeNode:
+		refs = append(refs, findBranchURLCalls(tpl, &n.BranchNode)...)
+	case *parse.WithNode:
+		refs = append(refs, findBranchURLCalls(tpl, &n.BranchNode)...)
+	case *parse.TemplateNode:
+		refs = append(refs, findURLCalls(tpl, n.Pipe)...)
+	}
+	return refs
+}
+
+// Read a url call with a literal route name from the command.
+func urlCall(tpl string, cmd *parse.CommandNode) (urlReference, bool) {
+	if len(cmd.Args) < 2 {
+		return urlReference{}, false
+	}
+	if ident, ok := cmd.Args[0].(*parse.IdentifierNode); !ok || ident.Ident != "url" {
+		return urlReference{}, false
+	}
+	name, ok := cmd.Args[1].(*parse.StringNode)
+	if !ok {
+		return urlReference{}, false
+	}
+	return urlReference{template: tpl, name: name.Text, params: len(cmd.Args) - 2}, true
+}
+
+func findBranchURLCalls(tpl string, n *parse.BranchNode) []urlReference {
+	refs := findURLCalls(tpl, n.Pipe)
+	refs = append(refs, findURLCalls(tpl, n.List)...)
+	return append(refs, findURLCalls(tpl, n.ElseList)...)
+}
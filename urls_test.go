@@ -0,0 +1,90 @@
+package milo_test
+
+import (
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/kendellfab/milo"
+	"github.com/kendellfab/milo/milotest"
+)
+
+func TestValidateRoutesWithoutTemplates(t *testing.T) {
+	app, err := milo.NewMilo()
+	if err != nil {
+		t.Fatal(err)
+	}
+	app.RegisterRenderer(milo.NewRenderer("", false, nil))
+	if err := app.ValidateRoutes(); err != nil {
+		t.Errorf("expected no error without a template dir, got %v", err)
+	}
+	app.RegisterRenderer(milo.NewRenderer(filepath.Join(t.TempDir(), "missing"), false, nil))
+	if err := app.ValidateRoutes(); err != nil {
+		t.Errorf("expected no error for a missing template dir, got %v", err)
+	}
+}
+
+func TestValidateRoutesChecksTemplateURLs(t *testing.T) {
+	dir := t.TempDir()
+	tpl := `<a href="{{url "post" "id" 1}}">{{url "missing"}}</a>`
+	if err := os.WriteFile(filepath.Join(dir, "index.tpl"), []byte(tpl), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	app, err := milo.NewMilo()
+	if err != nil {
+		t.Fatal(err)
+	}
+	app.RegisterRenderer(milo.NewRenderer(dir, false, nil))
+	app.Route("/posts/{id}", []string{http.MethodGet}, okHandler).Name("post")
+
+	err = app.ValidateRoutes()
+	if err == nil || !strings.Contains(err.Error(), `no route named "missing"`) {
+		t.Errorf("expected the missing route to be reported, got %v", err)
+	}
+	if strings.Contains(err.Error(), `"post"`) {
+		t.Errorf("expected the post route to validate, got %v", err)
+	}
+}
+
+func TestValidateRoutesCountsPipedParams(t *testing.T) {
+	dir := t.TempDir()
+	tpl := `<a href="{{ .ID | url "post" "id" }}">{{ 1 | url "post" }}</a>`
+	if err := os.WriteFile(filepath.Join(dir, "index.tpl"), []byte(tpl), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	app, err := milo.NewMilo()
+	if err != nil {
+		t.Fatal(err)
+	}
+	app.RegisterRenderer(milo.NewRenderer(dir, false, nil))
+	app.Route("/posts/{id}", []string{http.MethodGet}, okHandler).Name("post")
+
+	err = app.ValidateRoutes()
+	if err == nil || !strings.Contains(err.Error(), `route "post" takes 2 params, got 1`) {
+		t.Errorf("expected only the call missing its key to be reported, got %v", err)
+	}
+	if strings.Count(err.Error(), "takes 2 params") != 1 {
+		t.Errorf("expected the piped call with its key to validate, got %v", err)
+	}
+}
+
+func TestRenamingRouteIsRejected(t *testing.T) {
+	app, err := milo.NewMilo()
+	if err != nil {
+		t.Fatal(err)
+	}
+	app.Route("/a", []string{http.MethodGet}, okHandler).Name("a").Name("a").Name("b")
+
+	if err := app.ValidateRoutes(); err == nil || !strings.Contains(err.Error(), `already named "a"`) {
+		t.Errorf("expected the rename to be reported, got %v", err)
+	}
+	if u, err := app.URL("a"); err != nil || u != "/a" {
+		t.Errorf("expected url /a for the first name, got %q %v", u, err)
+	}
+	if _, err := app.URL("b"); err == nil {
+		t.Error("expected no route named b")
+	}
+	milotest.NewClient(t, app.Handler()).Get("/a").AssertStatus(http.StatusOK)
+}